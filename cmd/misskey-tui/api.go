@@ -1,39 +1,45 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"os"
+	"strings"
+
+	"github.com/yulog/misskey-tui/misskey"
 )
 
-// --- Misskey API Structs ---
+// --- Config ---
 
-type Note struct {
-	ID           string            `json:"id"`
-	Text         string            `json:"text"`
-	User         User              `json:"user"`
-	CreatedAt    string            `json:"createdAt"`
-	RepliesCount int               `json:"repliesCount"`
-	RenoteCount  int               `json:"renoteCount"`
-	Reactions    map[string]int    `json:"reactions"`
-	ReplyId      string            `json:"replyId,omitempty"`
-	Renote       *Note             `json:"renote,omitempty"`
+type Account struct {
+	Name        string         `json:"name"`
+	InstanceURL string         `json:"instance_url"`
+	AccessToken string         `json:"access_token"`
+	Columns     []ColumnConfig `json:"columns,omitempty"` // the column layout, left to right
 }
 
-type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Name     string `json:"name"`
+// ColumnConfig is a column of the column layout. Source is a timeline name
+// as used for the timeline view, "notifications", "search:<query>" or
+// "user:<id>"; Title is the column's header.
+type ColumnConfig struct {
+	Source string `json:"source"`
+	Title  string `json:"title"`
 }
 
-// --- Config ---
-
 type Config struct {
-	InstanceURL string `json:"instance_url"`
-	AccessToken string `json:"access_token"`
+	DefaultAccount    string    `json:"default_account,omitempty"`
+	Accounts          []Account `json:"accounts"`
+	FavoriteReactions []string  `json:"favorite_reactions,omitempty"`
+	Opener            string    `json:"opener,omitempty"`         // command attachments are opened with, e.g. "mpv"
+	ImageProtocol     string    `json:"image_protocol,omitempty"` // "auto", "kitty", "iterm2", "sixel", "halfblocks" or "none"
+
+	// Single-account fields used by older config files. loadConfig moves
+	// them into Accounts.
+	InstanceURL string `json:"instance_url,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
 }
 
 func loadConfig() (*Config, error) {
@@ -47,160 +53,216 @@ func loadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	if config.InstanceURL != "" || config.AccessToken != "" {
+		name := config.InstanceURL
+		if u, err := url.Parse(config.InstanceURL); err == nil && u.Host != "" {
+			name = u.Host
+		}
+		config.addAccount(Account{Name: name, InstanceURL: config.InstanceURL, AccessToken: config.AccessToken})
+		config.InstanceURL = ""
+		config.AccessToken = ""
+	}
+
 	return &config, nil
 }
 
-// --- API Helper ---
-
-func postRequest(client *http.Client, endpoint string, body []byte, responseData any) error {
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(body))
+func saveConfig(config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return os.WriteFile("config.json", append(data, '\n'), 0o600)
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+// account returns the account called name, or the default account when name
+// is empty.
+func (c *Config) account(name string) (*Account, error) {
+	if len(c.Accounts) == 0 {
+		return nil, errors.New("no accounts configured")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("API request to %s failed: %s", endpoint, resp.Status)
+	if name == "" {
+		name = c.DefaultAccount
 	}
-
-	if responseData != nil && resp.StatusCode != http.StatusNoContent {
-		if err := json.NewDecoder(resp.Body).Decode(responseData); err != nil {
-			return err
+	if name == "" {
+		return &c.Accounts[0], nil
+	}
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == name {
+			return &c.Accounts[i], nil
 		}
 	}
-
-	return nil
+	return nil, fmt.Errorf("no account named %q in config.json", name)
 }
 
-// --- API Functions ---
+var defaultFavoriteReactions = []string{"❤️", "👍", "😆", "🎉", "🤔", "😢", "🙏"}
 
-func fetchTimeline(client *http.Client, config *Config, timelineType string) ([]Note, error) {
-	endpointMap := map[string]string{
-		"home":   "/api/notes/timeline",
-		"local":  "/api/notes/local-timeline",
-		"social": "/api/notes/hybrid-timeline",
-		"global": "/api/notes/global-timeline",
-	}
-	endpoint, err := url.JoinPath(config.InstanceURL, endpointMap[timelineType])
-	if err != nil {
-		return nil, err
+// favoriteReactions returns the reactions shown in the picker's favourites
+// row. Custom emoji are written as ":name:".
+func (c *Config) favoriteReactions() []string {
+	if len(c.FavoriteReactions) == 0 {
+		return defaultFavoriteReactions
 	}
+	return c.FavoriteReactions
+}
 
-	reqBody, err := json.Marshal(map[string]any{"i": config.AccessToken, "limit": 30})
-	if err != nil {
-		return nil, err
+// addAccount adds account, replacing an existing account with the same name.
+// The first account added becomes the default.
+func (c *Config) addAccount(account Account) {
+	if c.DefaultAccount == "" {
+		c.DefaultAccount = account.Name
 	}
-
-	var notes []Note
-	err = postRequest(client, endpoint, reqBody, &notes)
-	return notes, err
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == account.Name {
+			c.Accounts[i] = account
+			return
+		}
+	}
+	c.Accounts = append(c.Accounts, account)
 }
 
-func createNote(client *http.Client, config *Config, text string, replyId string) error {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/notes/create")
-	if err != nil {
-		return err
-	}
+// --- API ---
 
-	payload := map[string]any{
-		"i":    config.AccessToken,
-		"text": text,
-	}
-	if replyId != "" {
-		payload["replyId"] = replyId
-	}
+const timelinePageSize = 30
 
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
+// maxNewerPages caps how many pages "load newer" fetches at once. The rest
+// is loaded the next time, continuing from the newest note loaded.
+const maxNewerPages = 10
 
-	return postRequest(client, endpoint, reqBody, nil)
+var timelineKinds = map[string]misskey.Timeline{
+	"home":   misskey.HomeTimeline,
+	"local":  misskey.LocalTimeline,
+	"social": misskey.HybridTimeline,
+	"global": misskey.GlobalTimeline,
 }
 
-func createReaction(client *http.Client, config *Config, noteId string, reaction string) error {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/notes/reactions/create")
-	if err != nil {
-		return err
-	}
-
-	reqBody, err := json.Marshal(map[string]any{"i": config.AccessToken, "noteId": noteId, "reaction": reaction})
-	if err != nil {
-		return err
-	}
+// antennaTimelinePrefix starts the names of antenna timelines, which are
+// followed by the antenna's ID.
+const antennaTimelinePrefix = "antenna:"
 
-	return postRequest(client, endpoint, reqBody, nil)
+func antennaTimeline(antennaID string) string {
+	return antennaTimelinePrefix + antennaID
 }
 
-func fetchNoteChildren(client *http.Client, config *Config, noteId string) ([]Note, error) {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/notes/children")
-	if err != nil {
-		return nil, err
-	}
+// channelTimelinePrefix starts the names of channel timelines, which are
+// followed by the channel's ID.
+const channelTimelinePrefix = "channel:"
 
-	reqBody, err := json.Marshal(map[string]any{"i": config.AccessToken, "noteId": noteId})
-	if err != nil {
-		return nil, err
-	}
+func channelTimeline(channelID string) string {
+	return channelTimelinePrefix + channelID
+}
+
+// listTimelinePrefix starts the names of user list timelines, which are
+// followed by the list's ID.
+const listTimelinePrefix = "list:"
 
-	var notes []Note
-	err = postRequest(client, endpoint, reqBody, &notes)
-	return notes, err
+func listTimeline(listID string) string {
+	return listTimelinePrefix + listID
 }
 
-func fetchSingleNote(client *http.Client, config *Config, noteId string) (*Note, error) {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/notes/show")
-	if err != nil {
-		return nil, err
-	}
+// notificationsTimeline is the name of the notifications column, which
+// lists notifications instead of notes.
+const notificationsTimeline = "notifications"
 
-	reqBody, err := json.Marshal(map[string]any{"i": config.AccessToken, "noteId": noteId})
-	if err != nil {
-		return nil, err
-	}
+// searchTimelinePrefix starts the names of note search columns, which are
+// followed by the query.
+const searchTimelinePrefix = "search:"
 
-	var note Note
-	err = postRequest(client, endpoint, reqBody, &note)
-	return &note, err
+func searchTimeline(query string) string {
+	return searchTimelinePrefix + query
 }
 
-func createRenote(client *http.Client, config *Config, noteId string) error {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/notes/create")
-	if err != nil {
-		return err
-	}
+// userTimelinePrefix starts the names of user columns, which are followed by
+// the user's ID.
+const userTimelinePrefix = "user:"
 
-	payload := map[string]any{
-		"i":        config.AccessToken,
-		"renoteId": noteId,
-	}
+func userTimeline(userID string) string {
+	return userTimelinePrefix + userID
+}
 
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		return err
+// fetchTimeline loads a page of notes from timeline, a built-in timeline, an
+// antenna, a channel, a user list, a search or a user's notes.
+func fetchTimeline(ctx context.Context, client *misskey.Client, timeline string, req misskey.TimelineRequest) ([]misskey.Note, error) {
+	if id, ok := strings.CutPrefix(timeline, antennaTimelinePrefix); ok {
+		return client.AntennaNotes(ctx, misskey.AntennaNotesRequest{
+			AntennaID: id,
+			Limit:     req.Limit,
+			SinceID:   req.SinceID,
+			UntilID:   req.UntilID,
+		})
 	}
-
-	return postRequest(client, endpoint, reqBody, nil)
+	if id, ok := strings.CutPrefix(timeline, channelTimelinePrefix); ok {
+		return client.ChannelTimeline(ctx, misskey.ChannelTimelineRequest{
+			ChannelID: id,
+			Limit:     req.Limit,
+			SinceID:   req.SinceID,
+			UntilID:   req.UntilID,
+		})
+	}
+	if id, ok := strings.CutPrefix(timeline, listTimelinePrefix); ok {
+		return client.UserListTimeline(ctx, misskey.UserListTimelineRequest{
+			ListID:  id,
+			Limit:   req.Limit,
+			SinceID: req.SinceID,
+			UntilID: req.UntilID,
+		})
+	}
+	if query, ok := strings.CutPrefix(timeline, searchTimelinePrefix); ok {
+		return searchNotes(ctx, client, query, req)
+	}
+	if id, ok := strings.CutPrefix(timeline, userTimelinePrefix); ok {
+		return client.UserNotes(ctx, misskey.UserNotesRequest{
+			UserID:  id,
+			Limit:   req.Limit,
+			SinceID: req.SinceID,
+			UntilID: req.UntilID,
+		})
+	}
+	return client.Timeline(ctx, timelineKinds[timeline], req)
 }
 
-func fetchMe(client *http.Client, config *Config) (*User, error) {
-	endpoint, err := url.JoinPath(config.InstanceURL, "/api/i")
-	if err != nil {
-		return nil, err
+// searchNotes searches notes for query, or for a hashtag if query is a
+// single "#tag".
+func searchNotes(ctx context.Context, client *misskey.Client, query string, req misskey.TimelineRequest) ([]misskey.Note, error) {
+	if tag, ok := strings.CutPrefix(query, "#"); ok && tag != "" && !strings.ContainsAny(tag, " \t#") {
+		return client.SearchNotesByTag(ctx, misskey.SearchNotesByTagRequest{Tag: tag, Limit: req.Limit, SinceID: req.SinceID, UntilID: req.UntilID})
 	}
+	return client.SearchNotes(ctx, misskey.SearchNotesRequest{Query: query, Limit: req.Limit, SinceID: req.SinceID, UntilID: req.UntilID})
+}
 
-	reqBody, err := json.Marshal(map[string]any{"i": config.AccessToken})
-	if err != nil {
-		return nil, err
+// streamChannel returns the streaming API channel carrying timeline and the
+// parameters to connect to it with. Searches and users' notes can't be
+// streamed.
+func streamChannel(timeline string) (string, map[string]any, bool) {
+	if timeline == notificationsTimeline {
+		return "main", map[string]any{}, true
+	}
+	if id, ok := strings.CutPrefix(timeline, antennaTimelinePrefix); ok {
+		return "antenna", map[string]any{"antennaId": id}, true
+	}
+	if id, ok := strings.CutPrefix(timeline, channelTimelinePrefix); ok {
+		return "channel", map[string]any{"channelId": id}, true
+	}
+	if id, ok := strings.CutPrefix(timeline, listTimelinePrefix); ok {
+		return "userList", map[string]any{"listId": id}, true
+	}
+	kind, ok := timelineKinds[timeline]
+	if !ok {
+		return "", nil, false
 	}
+	return kind.StreamChannel(), map[string]any{}, true
+}
+
+// Channel lists shown by the channel browser, in the order tab cycles
+// through them.
+var channelSources = []string{"followed", "featured", "owned"}
+
+// Visibilities in the order the composer cycles through them.
+var visibilities = []string{
+	misskey.VisibilityPublic,
+	misskey.VisibilityHome,
+	misskey.VisibilityFollowers,
+	misskey.VisibilitySpecified,
+}
 
-	var user User
-	err = postRequest(client, endpoint, reqBody, &user)
-	return &user, err
-}
\ No newline at end of file
+// Notification kinds shown in the notifications view.
+var notificationTypes = []string{"reply", "mention", "reaction", "renote", "quote", "follow", "pollEnded"}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxAttachments is the number of files Misskey accepts on a note.
+const maxAttachments = 16
+
+// completePath completes the file path being typed like a shell: a unique
+// match is filled in, with a trailing "/" for directories, and several
+// matches are completed to their longest common prefix. It also returns the
+// matching names.
+func completePath(input string) (string, []string) {
+	if input == "~" {
+		return input + string(filepath.Separator), nil
+	}
+	// Split what the user typed, so that the completion goes after it
+	// unchanged (e.g. after "~/"), and only expand it to read the directory.
+	typed, prefix := filepath.Split(input)
+	dir := expandHome(typed)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return input, nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+			name += string(filepath.Separator)
+		}
+		matches = append(matches, name)
+	}
+	if len(matches) == 0 {
+		return input, nil
+	}
+
+	common := matches[0]
+	for _, match := range matches[1:] {
+		i := 0
+		for i < len(common) && i < len(match) && common[i] == match[i] {
+			i++
+		}
+		common = common[:i]
+	}
+	return typed + common, matches
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
+
+// formatSize formats a file size in bytes for display.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
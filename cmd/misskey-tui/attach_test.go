@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompletePath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, name := range []string{"photo.png", "photos", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(home, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(home, "photos", "cat.jpg"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(home)
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		matches []string
+	}{
+		{
+			name: "home",
+			in:   "~",
+			want: "~/",
+		},
+		{
+			name:    "home directory",
+			in:      "~/",
+			want:    "~/photo",
+			matches: []string{"photo.png/", "photos/"},
+		},
+		{
+			name:    "tilde in a name",
+			in:      "~x",
+			want:    "~x",
+			matches: nil,
+		},
+		{
+			name:    "relative path",
+			in:      "photos/c",
+			want:    "photos/cat.jpg",
+			matches: []string{"cat.jpg"},
+		},
+		{
+			name:    "hidden files",
+			in:      ".h",
+			want:    ".hidden/",
+			matches: []string{".hidden/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, matches := completePath(tt.in)
+			if got != tt.want || !reflect.DeepEqual(matches, tt.matches) {
+				t.Errorf("completePath(%q) = %q, %q, want %q, %q", tt.in, got, matches, tt.want, tt.matches)
+			}
+		})
+	}
+}
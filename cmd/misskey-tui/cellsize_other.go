@@ -0,0 +1,9 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+
+package main
+
+// terminalCellSize returns a typical cell size where the terminal can't be
+// asked for it.
+func terminalCellSize() cellSize {
+	return defaultCellSize
+}
@@ -0,0 +1,19 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalCellSize asks the terminal for its size in pixels to work out how
+// large a character cell is.
+func terminalCellSize() cellSize {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 || ws.Xpixel == 0 || ws.Ypixel == 0 {
+		return defaultCellSize
+	}
+	return cellSize{width: int(ws.Xpixel / ws.Col), height: int(ws.Ypixel / ws.Row)}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// minColumnWidth is the narrowest a column gets. Columns that don't fit
+// scroll into view as the focus moves to them.
+const minColumnWidth = 40
+
+// defaultColumns is the column layout of accounts that haven't set one up.
+var defaultColumns = []ColumnConfig{
+	{Source: "home", Title: "HOME"},
+	{Source: notificationsTimeline, Title: "NOTIFICATIONS"},
+	{Source: "local", Title: "LOCAL"},
+}
+
+// column is a timeline in the column layout. Each column loads and pages
+// through its timeline on its own.
+type column struct {
+	ColumnConfig
+	list        list.Model
+	loading     bool
+	loadingMore bool
+	end         bool // no older items left
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+func newColumn(config ColumnConfig) *column {
+	l := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &column{ColumnConfig: config, list: l, loading: true, ctx: ctx, cancel: cancel}
+}
+
+// openColumns shows the column layout, loading the account's columns the
+// first time. The layout is kept on the timeline screen, so the columns
+// keep their items when it is opened again.
+func (m *model) openColumns() tea.Cmd {
+	root := m.root()
+	if root.columns != nil {
+		m.push(root.columns)
+		return nil
+	}
+	s := &columnsScreen{}
+	var cmds []tea.Cmd
+	for _, config := range m.columnConfigs() {
+		col := newColumn(config)
+		s.columns = append(s.columns, col)
+		cmds = append(cmds, m.fetchColumnCmd(col, "", ""))
+	}
+	root.columns = s
+	m.push(s)
+	m.stream.subscribe(m.streamTimelines()...)
+	return tea.Batch(cmds...)
+}
+
+// closeColumns drops the columns and cancels their requests.
+func (m *model) closeColumns() {
+	for _, col := range m.columns() {
+		col.cancel()
+	}
+	m.root().columns = nil
+}
+
+// columns returns the columns of the layout, or nil if it hasn't been
+// opened.
+func (m *model) columns() []*column {
+	if s := m.root().columns; s != nil {
+		return s.columns
+	}
+	return nil
+}
+
+// columnConfigs returns the column layout: the open columns, or the layout
+// saved for the account.
+func (m *model) columnConfigs() []ColumnConfig {
+	if columns := m.columns(); columns != nil {
+		configs := make([]ColumnConfig, len(columns))
+		for i, col := range columns {
+			configs[i] = col.ColumnConfig
+		}
+		return configs
+	}
+	if len(m.account.Columns) > 0 {
+		return slices.Clone(m.account.Columns)
+	}
+	return slices.Clone(defaultColumns)
+}
+
+// saveColumns stores the layout of the open columns in config.json.
+func (m *model) saveColumns() error {
+	m.account.Columns = m.columnConfigs()
+	return saveConfig(m.config)
+}
+
+// addColumn adds a column for config to the right end of the layout and
+// saves it.
+func (m *model) addColumn(config ColumnConfig) tea.Cmd {
+	clearStatus := tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+	configs := m.columnConfigs()
+	if slices.ContainsFunc(configs, func(c ColumnConfig) bool { return c.Source == config.Source }) {
+		m.statusMessage = fmt.Sprintf("%s is already a column", config.Title)
+		return clearStatus
+	}
+
+	var cmd tea.Cmd
+	if s := m.root().columns; s != nil {
+		col := newColumn(config)
+		s.columns = append(s.columns, col)
+		m.stream.subscribe(m.streamTimelines()...)
+		cmd = m.fetchColumnCmd(col, "", "")
+	} else {
+		m.account.Columns = append(configs, config)
+	}
+	m.statusMessage = fmt.Sprintf("Added column %s", config.Title)
+	if err := m.saveColumns(); err != nil {
+		m.statusMessage = fmt.Sprintf("Added column %s, but failed to save config.json: %v", config.Title, err)
+	}
+	return tea.Batch(cmd, clearStatus)
+}
+
+// removeColumn removes the focused column and saves the layout.
+func (s *columnsScreen) removeColumn(m *model) tea.Cmd {
+	clearStatus := tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+	if len(s.columns) <= 1 {
+		m.statusMessage = "Cannot remove the last column"
+		return clearStatus
+	}
+	col := s.columns[s.focus]
+	col.cancel()
+	s.columns = slices.Delete(s.columns, s.focus, s.focus+1)
+	s.focus = min(s.focus, len(s.columns)-1)
+	m.stream.subscribe(m.streamTimelines()...)
+	m.statusMessage = fmt.Sprintf("Removed column %s", col.Title)
+	if err := m.saveColumns(); err != nil {
+		m.statusMessage = fmt.Sprintf("Failed to save config.json: %v", err)
+	}
+	return clearStatus
+}
+
+// moveColumn moves the focused column by delta places and saves the layout.
+func (s *columnsScreen) moveColumn(m *model, delta int) tea.Cmd {
+	i, j := s.focus, s.focus+delta
+	if j < 0 || j >= len(s.columns) {
+		return nil
+	}
+	s.columns[i], s.columns[j] = s.columns[j], s.columns[i]
+	s.focus = j
+	if err := m.saveColumns(); err != nil {
+		m.statusMessage = fmt.Sprintf("Failed to save config.json: %v", err)
+		return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+	}
+	return nil
+}
+
+// focusedColumn returns the focused column, or nil if there are none.
+func (s *columnsScreen) focusedColumn() *column {
+	if s.focus >= len(s.columns) {
+		return nil
+	}
+	return s.columns[s.focus]
+}
+
+// columnNote returns the note selected in the focused column: a timeline's
+// note or the note a notification is about.
+func (s *columnsScreen) columnNote() *misskey.Note {
+	col := s.focusedColumn()
+	if col == nil {
+		return nil
+	}
+	switch it := col.list.SelectedItem().(type) {
+	case item:
+		return &it.note
+	case notificationItem:
+		return it.notification.Note
+	}
+	return nil
+}
+
+// streamTimelines returns the timelines to stream: the timeline view's and
+// the columns'.
+func (m *model) streamTimelines() []string {
+	timelines := []string{m.root().timeline}
+	for _, col := range m.columns() {
+		timelines = append(timelines, col.Source)
+	}
+	return timelines
+}
+
+// loadNewerColumn loads the items newer than the top of col, or reloads it
+// if it is empty.
+func (m *model) loadNewerColumn(col *column) tea.Cmd {
+	if col.loading || col.loadingMore {
+		return nil
+	}
+	items := col.list.Items()
+	if len(items) == 0 {
+		col.loading = true
+		return m.fetchColumnCmd(col, "", "")
+	}
+	col.loadingMore = true
+	return m.fetchColumnCmd(col, columnItemID(items[0]), "")
+}
+
+// loadOlderColumn loads the next page of col once its cursor reaches the
+// last item.
+func (m *model) loadOlderColumn(col *column) tea.Cmd {
+	items := col.list.Items()
+	if col.loading || col.loadingMore || col.end || len(items) == 0 || col.list.Index() < len(items)-1 {
+		return nil
+	}
+	col.loadingMore = true
+	return m.fetchColumnCmd(col, "", columnItemID(items[len(items)-1]))
+}
+
+// addColumnItems adds the items missing from col to its top or bottom and
+// returns how many were added. Adding to the top keeps the cursor on the
+// item the user was looking at unless it was already at the top.
+func addColumnItems(col *column, items []list.Item, top bool) int {
+	ids := map[string]bool{}
+	for _, listItem := range col.list.Items() {
+		ids[columnItemID(listItem)] = true
+	}
+	var newItems []list.Item
+	for _, listItem := range items {
+		if id := columnItemID(listItem); !ids[id] {
+			ids[id] = true
+			newItems = append(newItems, listItem)
+		}
+	}
+	if len(newItems) == 0 {
+		return 0
+	}
+	if !top {
+		col.list.SetItems(append(col.list.Items(), newItems...))
+		return len(newItems)
+	}
+	index := col.list.Index()
+	col.list.SetItems(append(newItems, col.list.Items()...))
+	if index > 0 {
+		col.list.Select(index + len(newItems))
+	}
+	return len(newItems)
+}
+
+// columnItemID is the ID of the note or notification behind a column's item.
+func columnItemID(listItem list.Item) string {
+	switch it := listItem.(type) {
+	case item:
+		return it.note.ID
+	case notificationItem:
+		return it.notification.ID
+	}
+	return ""
+}
+
+// columnsScreen is the column layout.
+type columnsScreen struct {
+	columns []*column
+	focus   int // index of the focused column
+}
+
+func (s *columnsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	col := s.focusedColumn()
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.ColumnQuit):
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.ColumnNext):
+			if len(s.columns) > 0 {
+				s.focus = (s.focus + 1) % len(s.columns)
+			}
+			return nil
+		case key.Matches(msg, m.keys.ColumnPrev):
+			if len(s.columns) > 0 {
+				s.focus = (s.focus + len(s.columns) - 1) % len(s.columns)
+			}
+			return nil
+		case key.Matches(msg, m.keys.ColumnMoveLeft):
+			return s.moveColumn(m, -1)
+		case key.Matches(msg, m.keys.ColumnMoveRight):
+			return s.moveColumn(m, 1)
+		case key.Matches(msg, m.keys.ColumnRemove):
+			return s.removeColumn(m)
+		case col == nil:
+			return nil
+		case key.Matches(msg, m.keys.LoadNewer):
+			return m.loadNewerColumn(col)
+		case key.Matches(msg, m.keys.ToggleCW):
+			if selectedItem, ok := col.list.SelectedItem().(item); ok && noteCW(&selectedItem.note) != "" {
+				selectedItem.expanded = !selectedItem.expanded
+				return col.list.SetItem(col.list.Index(), selectedItem)
+			}
+			return nil
+		case key.Matches(msg, m.keys.Detail):
+			switch selectedItem := col.list.SelectedItem().(type) {
+			case item:
+				return m.openDetail(&selectedItem.note)
+			case notificationItem:
+				if note := selectedItem.notification.Note; note != nil {
+					return m.openDetail(note)
+				} else if user := selectedItem.notification.User; user != nil {
+					return m.openUserProfile(*user)
+				}
+			}
+			return nil
+		}
+		if note := s.columnNote(); note != nil {
+			switch {
+			case key.Matches(msg, m.keys.Reply):
+				return m.openComposer(note)
+			case key.Matches(msg, m.keys.React):
+				return m.openReactionPicker(note)
+			case key.Matches(msg, m.keys.Renote):
+				return m.createRenoteCmd(note.ID)
+			case key.Matches(msg, m.keys.Quote):
+				return m.openQuoteComposer(note)
+			case key.Matches(msg, m.keys.Profile):
+				if note.Renote != nil && note.Text == "" {
+					note = note.Renote
+				}
+				return m.openUserProfile(note.User)
+			}
+		}
+	}
+
+	if col == nil {
+		return nil
+	}
+	var cmd tea.Cmd
+	col.list, cmd = col.list.Update(msg)
+	return tea.Batch(cmd, m.loadOlderColumn(col))
+}
+
+// View lays the columns out side by side, as many as fit, with the focused
+// column always in view.
+func (s *columnsScreen) View(m *model) string {
+	status := m.statusBarView()
+	help := m.help.ShortHelpView([]key.Binding{
+		m.keys.ColumnNext,
+		m.keys.ColumnPrev,
+		m.keys.ColumnMoveLeft,
+		m.keys.ColumnMoveRight,
+		m.keys.ColumnRemove,
+		m.keys.LoadNewer,
+		m.keys.Detail,
+		m.keys.Reply,
+		m.keys.React,
+		m.keys.Renote,
+		m.keys.ColumnQuit,
+	})
+	h, _ := docStyle.GetFrameSize()
+	width := max(m.width-h, 0)
+	height := max(m.height-lipgloss.Height(status)-lipgloss.Height(help), 0)
+
+	count := max(min(len(s.columns), width/minColumnWidth), 1)
+	first := max(s.focus-count+1, 0)
+	columnWidth := width / count
+
+	var views []string
+	for i, col := range s.columns[first:min(first+count, len(s.columns))] {
+		style, titleStyle := unfocusedColumnStyle, inactiveTabStyle
+		if first+i == s.focus {
+			style, titleStyle = focusedColumnStyle, activeTabStyle
+		}
+		innerWidth := max(columnWidth-style.GetHorizontalFrameSize(), 0)
+		innerHeight := max(height-style.GetVerticalFrameSize(), 0)
+		title := titleStyle.MaxWidth(innerWidth).Render(col.Title)
+
+		var body string
+		if col.loading {
+			body = metadataStyle.Render(" Loading...")
+		} else {
+			col.list.SetSize(innerWidth, max(innerHeight-lipgloss.Height(title), 0))
+			body = col.list.View()
+		}
+		views = append(views, style.Width(innerWidth).Height(innerHeight).MaxHeight(height).Render(title+"\n"+body))
+	}
+
+	content := lipgloss.JoinHorizontal(lipgloss.Top, views...)
+	return docStyle.Render(content) + "\n" + help + "\n" + status
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yulog/misskey-tui/misskey"
 )
 
-func (m model) fetchTimelineCmd() tea.Cmd {
+func (m model) fetchTimelineCmd(s *timelineScreen) tea.Cmd {
+	ctx, timeline := m.timelineCtx, s.timeline
 	return func() tea.Msg {
-		notes, err := fetchTimeline(m.client, m.config, m.timeline)
+		notes, err := fetchTimeline(ctx, m.client, timeline, misskey.TimelineRequest{Limit: timelinePageSize})
 		if err != nil {
 			return errorMsg{err: err}
 		}
@@ -15,47 +23,453 @@ func (m model) fetchTimelineCmd() tea.Cmd {
 		for i, note := range notes {
 			items[i] = item{note: note}
 		}
-		return timelineLoadedMsg{items: items}
+		return timelineLoadedMsg{screen: s, items: items}
+	}
+}
+
+func (m model) fetchOlderNotesCmd(s *timelineScreen, untilId string) tea.Cmd {
+	ctx, timeline := m.timelineCtx, s.timeline
+	return func() tea.Msg {
+		notes, err := fetchTimeline(ctx, m.client, timeline, misskey.TimelineRequest{Limit: timelinePageSize, UntilID: untilId})
+		return olderNotesLoadedMsg{screen: s, timeline: timeline, notes: notes, err: err}
+	}
+}
+
+// fetchNewerNotesCmd loads the notes newer than sinceId. It keeps paging
+// until a short page comes back, so that no notes are skipped between the
+// top of the timeline and the newest note, up to maxNewerPages.
+func (m model) fetchNewerNotesCmd(s *timelineScreen, sinceId string) tea.Cmd {
+	ctx, timeline := m.timelineCtx, s.timeline
+	return func() tea.Msg {
+		var all []misskey.Note
+		for range maxNewerPages {
+			notes, err := fetchTimeline(ctx, m.client, timeline, misskey.TimelineRequest{Limit: timelinePageSize, SinceID: sinceId})
+			if err != nil {
+				return newerNotesLoadedMsg{screen: s, timeline: timeline, err: err}
+			}
+			all = append(all, notes...)
+			if len(notes) < timelinePageSize {
+				return newerNotesLoadedMsg{screen: s, timeline: timeline, notes: all}
+			}
+			newest := slices.MaxFunc(notes, func(a, b misskey.Note) int {
+				return strings.Compare(a.CreatedAt, b.CreatedAt)
+			})
+			sinceId = newest.ID
+		}
+		return newerNotesLoadedMsg{screen: s, timeline: timeline, notes: all, more: true}
+	}
+}
+
+// fetchColumnCmd loads a page of col: its latest items, or the items newer
+// than sinceID or older than untilID.
+func (m model) fetchColumnCmd(col *column, sinceID, untilID string) tea.Cmd {
+	ctx, source := col.ctx, col.Source
+	return func() tea.Msg {
+		var items []list.Item
+		if source == notificationsTimeline {
+			notifications, err := m.client.Notifications(ctx, misskey.NotificationsRequest{
+				Limit:        timelinePageSize,
+				SinceID:      sinceID,
+				UntilID:      untilID,
+				IncludeTypes: notificationTypes,
+			})
+			for _, notification := range notifications {
+				items = append(items, notificationItem{notification: notification})
+			}
+			return columnLoadedMsg{column: col, sinceID: sinceID, untilID: untilID, items: items, err: err}
+		}
+		notes, err := fetchTimeline(ctx, m.client, source, misskey.TimelineRequest{Limit: timelinePageSize, SinceID: sinceID, UntilID: untilID})
+		for _, note := range notes {
+			items = append(items, item{note: note})
+		}
+		return columnLoadedMsg{column: col, sinceID: sinceID, untilID: untilID, items: items, err: err}
+	}
+}
+
+func (m model) fetchNotificationsCmd(s *notificationsScreen) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		notifications, err := m.client.Notifications(ctx, misskey.NotificationsRequest{
+			Limit:        timelinePageSize,
+			IncludeTypes: notificationTypes,
+		})
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		items := make([]list.Item, len(notifications))
+		for i, notification := range notifications {
+			items[i] = notificationItem{notification: notification}
+		}
+		return notificationsLoadedMsg{screen: s, items: items}
+	}
+}
+
+// fetchProfileCmd loads a user's profile and their latest notes into s.
+func (m model) fetchProfileCmd(s *profileScreen, req misskey.ShowUserRequest) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		user, err := m.client.ShowUser(ctx, req)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		notes, err := m.client.UserNotes(ctx, misskey.UserNotesRequest{UserID: user.ID, Limit: timelinePageSize})
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return profileLoadedMsg{screen: s, user: user, notes: notes}
+	}
+}
+
+func (m model) fetchProfileNotesCmd(s *profileScreen, untilID string) tea.Cmd {
+	ctx, userID := m.viewCtx, s.user.ID
+	return func() tea.Msg {
+		notes, err := m.client.UserNotes(ctx, misskey.UserNotesRequest{UserID: userID, Limit: timelinePageSize, UntilID: untilID})
+		return profileNotesLoadedMsg{screen: s, notes: notes, err: err}
+	}
+}
+
+// searchNotesCmd searches notes for query, or for a hashtag if query is a
+// single "#tag", into s. untilID pages through older results.
+func (m model) searchNotesCmd(s *searchScreen, query, untilID string) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		notes, err := searchNotes(ctx, m.client, query, misskey.TimelineRequest{Limit: timelinePageSize, UntilID: untilID})
+		if err != nil && untilID == "" {
+			return errorMsg{err: err}
+		}
+		return searchResultsMsg{screen: s, query: query, untilID: untilID, notes: notes, err: err}
+	}
+}
+
+// searchUsersCmd looks up users for query. Profile and note URLs are
+// resolved with ap/show and a full "@user@host" with users/show, both of
+// which fetch users unknown to the server; "@user" searches by username and
+// anything else by name, paged by offset. The results go to s.
+func (m model) searchUsersCmd(s *userSearchScreen, query string, offset int) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		msg := userSearchResultsMsg{screen: s, query: query, offset: offset}
+		username, host := parseAcct(query)
+		var err error
+		switch {
+		case strings.HasPrefix(query, "https://") || strings.HasPrefix(query, "http://"):
+			var user *misskey.User
+			user, msg.note, err = m.client.APShow(ctx, query)
+			if user != nil {
+				msg.users = []misskey.User{*user}
+			}
+		case host != "" && !strings.ContainsAny(query, " \t"):
+			if strings.EqualFold(host, m.client.Host()) {
+				host = ""
+			}
+			var user *misskey.UserDetail
+			user, err = m.client.ShowUser(ctx, misskey.ShowUserRequest{Username: username, Host: host})
+			if user != nil {
+				msg.users = []misskey.User{user.User}
+			}
+		case strings.HasPrefix(query, "@"):
+			msg.users, err = m.client.SearchUsersByUsername(ctx, misskey.SearchUsersByUsernameRequest{Username: username, Limit: timelinePageSize})
+		default:
+			msg.users, err = m.client.SearchUsers(ctx, misskey.SearchUsersRequest{Query: query, Limit: timelinePageSize, Offset: offset})
+			msg.paged = true
+		}
+		if err != nil && offset == 0 {
+			return errorMsg{err: err}
+		}
+		msg.err = err
+		return msg
+	}
+}
+
+// followCmd follows ("follow") or unfollows ("unfollow") a user, or cancels
+// a follow request to them ("cancel").
+func (m model) followCmd(userID, action string) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		var err error
+		switch action {
+		case "follow":
+			err = m.client.Follow(ctx, userID)
+		case "unfollow":
+			err = m.client.Unfollow(ctx, userID)
+		case "cancel":
+			err = m.client.CancelFollowRequest(ctx, userID)
+		}
+		return followedMsg{userID: userID, action: action, err: err}
 	}
 }
 
-func (m model) fetchParentNoteCmd(noteId string) tea.Cmd {
+func (m model) fetchAntennasCmd(s *antennasScreen) tea.Cmd {
+	ctx := m.viewCtx
 	return func() tea.Msg {
-		note, err := fetchSingleNote(m.client, m.config, noteId)
+		antennas, err := m.client.Antennas(ctx)
 		if err != nil {
 			return errorMsg{err: err}
 		}
-		return parentNoteLoadedMsg{note: note}
+		return antennasLoadedMsg{screen: s, antennas: antennas}
 	}
 }
 
-func (m model) fetchNoteChildrenCmd(noteId string) tea.Cmd {
+// fetchChannelsCmd loads the list of s: "followed", "featured" or "owned"
+// channels.
+func (m model) fetchChannelsCmd(s *channelsScreen, source string) tea.Cmd {
+	ctx := m.viewCtx
 	return func() tea.Msg {
-		notes, err := fetchNoteChildren(m.client, m.config, noteId)
+		// 100 is the most the API returns at once.
+		req := misskey.ChannelsRequest{Limit: 100}
+		var channels []misskey.Channel
+		var err error
+		switch source {
+		case "featured":
+			channels, err = m.client.FeaturedChannels(ctx)
+		case "owned":
+			channels, err = m.client.OwnedChannels(ctx, req)
+		default:
+			channels, err = m.client.FollowedChannels(ctx, req)
+		}
 		if err != nil {
 			return errorMsg{err: err}
 		}
-		return childrenNotesLoadedMsg{notes: notes}
+		return channelsLoadedMsg{screen: s, source: source, channels: channels}
 	}
 }
 
-func (m model) createNoteCmd(text string, replyId string) tea.Cmd {
+// followChannelCmd follows channel, or unfollows it if already following.
+func (m model) followChannelCmd(channel misskey.Channel) tea.Cmd {
+	ctx := context.Background()
 	return func() tea.Msg {
-		err := createNote(m.client, m.config, text, replyId)
-		return notePostedMsg{err: err}
+		var err error
+		if channel.IsFollowing {
+			err = m.client.UnfollowChannel(ctx, channel.ID)
+		} else {
+			err = m.client.FollowChannel(ctx, channel.ID)
+		}
+		if err == nil {
+			channel.IsFollowing = !channel.IsFollowing
+		}
+		return channelFollowedMsg{channel: channel, err: err}
+	}
+}
+
+func (m model) fetchUserListsCmd(s *listsScreen) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		lists, err := m.client.UserLists(ctx)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return userListsLoadedMsg{screen: s, lists: lists}
+	}
+}
+
+// listMemberCmd adds user to userList, or removes them from it.
+func (m model) listMemberCmd(userList misskey.UserList, user misskey.User, add bool) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		req := misskey.UserListMemberRequest{ListID: userList.ID, UserID: user.ID}
+		var err error
+		if add {
+			err = m.client.AddToUserList(ctx, req)
+		} else {
+			err = m.client.RemoveFromUserList(ctx, req)
+		}
+		if err == nil {
+			if add {
+				userList.UserIDs = append(slices.Clone(userList.UserIDs), user.ID)
+			} else {
+				userList.UserIDs = slices.DeleteFunc(slices.Clone(userList.UserIDs), func(id string) bool { return id == user.ID })
+			}
+		}
+		return listMembershipMsg{list: userList, user: user, added: add, err: err}
+	}
+}
+
+func (m model) fetchParentNoteCmd(s *detailScreen, noteId string) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		note, err := m.client.ShowNote(ctx, misskey.NoteRequest{NoteID: noteId})
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return parentNoteLoadedMsg{screen: s, note: note}
+	}
+}
+
+func (m model) fetchNoteChildrenCmd(s *detailScreen, noteId string) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		notes, err := m.client.NoteChildren(ctx, misskey.NoteChildrenRequest{NoteID: noteId})
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return childrenNotesLoadedMsg{screen: s, notes: notes}
+	}
+}
+
+func (m model) fetchThreadCmd(s *threadScreen, note *misskey.Note) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		root, truncated, err := fetchThread(ctx, m.client, note)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return threadLoadedMsg{screen: s, root: root, truncated: truncated}
+	}
+}
+
+func (m model) createNoteCmd(s *postingScreen, req misskey.CreateNoteRequest) tea.Cmd {
+	return func() tea.Msg {
+		_, err := m.client.CreateNote(context.Background(), req)
+		return notePostedMsg{composer: s, err: err}
 	}
 }
 
 func (m model) createRenoteCmd(noteId string) tea.Cmd {
+	ctx := context.Background()
 	return func() tea.Msg {
-		err := createRenote(m.client, m.config, noteId)
+		_, err := m.client.Renote(ctx, noteId)
 		return noteRenotedMsg{err: err}
 	}
 }
 
-func (m model) createReactionCmd(noteId string, reaction string) tea.Cmd {
+// createReactionCmd reacts to a note. If replace is set, the existing
+// reaction is removed first since Misskey allows only one per user.
+func (m model) createReactionCmd(noteId string, reaction string, replace bool) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		if replace {
+			if err := m.client.DeleteReaction(ctx, misskey.NoteRequest{NoteID: noteId}); err != nil {
+				return reactionResultMsg{noteId: noteId, reaction: reaction, err: err}
+			}
+		}
+		err := m.client.CreateReaction(ctx, misskey.ReactionRequest{NoteID: noteId, Reaction: reaction})
+		return reactionResultMsg{noteId: noteId, reaction: reaction, err: err}
+	}
+}
+
+func (m model) deleteReactionCmd(noteId string) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		err := m.client.DeleteReaction(ctx, misskey.NoteRequest{NoteID: noteId})
+		return reactionDeletedMsg{noteId: noteId, err: err}
+	}
+}
+
+func (m model) fetchEmojisCmd() tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		emojis, err := client.Emojis(context.Background())
+		if err != nil {
+			return emojisLoadedMsg{client: client, err: err}
+		}
+		items := make([]list.Item, len(emojis))
+		for i, emoji := range emojis {
+			items[i] = emojiItem{emoji: emoji}
+		}
+		return emojisLoadedMsg{client: client, items: items}
+	}
+}
+
+func (m model) voteCmd(noteId string, choice int) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		err := m.client.Vote(ctx, misskey.PollVoteRequest{NoteID: noteId, Choice: choice})
+		return pollVotedMsg{noteId: noteId, choice: choice, err: err}
+	}
+}
+
+// uploadFileCmd uploads the file at path and attaches it to s.
+func (m model) uploadFileCmd(s *postingScreen, path string) tea.Cmd {
+	ctx := context.Background()
 	return func() tea.Msg {
-		err := createReaction(m.client, m.config, noteId, reaction)
-		return reactionResultMsg{err: err}
+		f, err := os.Open(path)
+		if err != nil {
+			return fileUploadedMsg{composer: s, err: err}
+		}
+		defer f.Close()
+		file, err := m.client.UploadFile(ctx, f, misskey.UploadFileRequest{Name: filepath.Base(path)})
+		return fileUploadedMsg{composer: s, file: file, err: err}
+	}
+}
+
+func (m model) fetchDriveFilesCmd(s *driveScreen) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		files, err := m.client.DriveFiles(ctx, misskey.DriveFilesRequest{Limit: 100})
+		if err != nil {
+			return errorMsg{err}
+		}
+		return driveFilesLoadedMsg{screen: s, files: files}
+	}
+}
+
+func (m model) updateDriveFileCmd(s *postingScreen, req misskey.UpdateDriveFileRequest) tea.Cmd {
+	ctx := context.Background()
+	return func() tea.Msg {
+		file, err := m.client.UpdateDriveFile(ctx, req)
+		return driveFileUpdatedMsg{composer: s, file: file, err: err}
+	}
+}
+
+// searchRecipientsCmd looks up users for the recipient picker s. query is
+// "username" or "username@host", with or without a leading "@".
+func (m model) searchRecipientsCmd(s *recipientsScreen, query string) tea.Cmd {
+	username, host, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(query), "@"), "@")
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		users, err := m.client.SearchUsersByUsername(ctx, misskey.SearchUsersByUsernameRequest{
+			Username: username,
+			Host:     host,
+			Limit:    20,
+		})
+		return recipientsFoundMsg{screen: s, users: users, err: err}
+	}
+}
+
+// fetchRecipientsCmd loads the users a reply to a "specified" note is
+// addressed to by default into s.
+func (m model) fetchRecipientsCmd(s *postingScreen, userIDs []string) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		users, err := m.client.Users(ctx, userIDs)
+		return recipientsLoadedMsg{composer: s, users: users, err: err}
+	}
+}
+
+// loadImageCmd loads the first image of urls that can be previewed into the
+// image cache under key, unless it is already loaded or loading.
+func (m model) loadImageCmd(key string, urls ...string) tea.Cmd {
+	if m.graphics == graphicsNone || !m.images.startLoading(key) {
+		return nil
+	}
+	return func() tea.Msg {
+		m.images.load(context.Background(), key, urls...)
+		return imageLoadedMsg{key: key}
+	}
+}
+
+func (m model) switchAccountCmd(account *Account) tea.Cmd {
+	ctx := m.viewCtx
+	return func() tea.Msg {
+		client := misskey.NewClient(account.InstanceURL, account.AccessToken)
+		user, err := client.Me(ctx)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		return accountSwitchedMsg{account: account, client: client, user: user}
+	}
+}
+
+func (m model) waitForStreamCmd() tea.Cmd {
+	if m.stream == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case msg := <-m.stream.events:
+			return msg
+		case <-m.stream.done:
+			return nil
+		}
 	}
 }
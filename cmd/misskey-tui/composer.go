@@ -0,0 +1,666 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// postingScreen is the composer. The recipient picker, poll editor,
+// attachments and drive picker are pushed on top of it, and closing the
+// composer removes them all.
+type postingScreen struct {
+	textarea    textarea.Model
+	cwInput     textinput.Model
+	replyTo     *misskey.Note // the note being replied to
+	quote       *misskey.Note // the note being quoted
+	visibility  string
+	localOnly   bool
+	recipients  []misskey.User // visible users of a "specified" note
+	poll        *pollEditor
+	attachments []misskey.DriveFile
+	channel     *misskey.Channel // channel the note is posted to
+}
+
+func newPostingScreen(m *model) *postingScreen {
+	ta := textarea.New()
+	ta.Placeholder = "What's on your mind?"
+
+	cw := textinput.New()
+	cw.Prompt = "CW: "
+	cw.Placeholder = "Content warning (optional)"
+
+	s := &postingScreen{textarea: ta, cwInput: cw, visibility: misskey.VisibilityPublic}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openComposer opens the composer, replying to replyTo if it is set.
+// Replies start with the parent's content warning and visibility, as on the
+// web client; replies to a "specified" note go to the same users and its
+// author.
+func (m *model) openComposer(replyTo *misskey.Note) tea.Cmd {
+	s := newPostingScreen(m)
+	m.push(s)
+	// Notes posted from a channel's timeline, and replies to channel notes,
+	// go to the channel.
+	if replyTo != nil {
+		s.channel = replyTo.Channel
+	} else if root := m.root(); root.channel != nil && root.timeline == channelTimeline(root.channel.ID) {
+		s.channel = root.channel
+	}
+	cmds := []tea.Cmd{s.textarea.Focus()}
+	if replyTo != nil {
+		s.replyTo = replyTo
+		s.textarea.Placeholder = fmt.Sprintf("Replying to @%s...", replyTo.User.Username)
+		s.cwInput.SetValue(noteCW(replyTo))
+		if replyTo.Visibility != "" {
+			s.visibility = replyTo.Visibility
+		}
+		s.localOnly = replyTo.LocalOnly
+		if s.visibility == misskey.VisibilitySpecified {
+			if replyTo.User.ID != m.userID {
+				s.recipients = append(s.recipients, replyTo.User)
+			}
+			var ids []string
+			for _, id := range replyTo.VisibleUserIDs {
+				if id != m.userID && id != replyTo.User.ID {
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) > 0 {
+				cmds = append(cmds, m.fetchRecipientsCmd(s, ids))
+			}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// openQuoteComposer opens the composer to quote note.
+func (m *model) openQuoteComposer(note *misskey.Note) tea.Cmd {
+	if note.Renote != nil && note.Text == "" {
+		note = note.Renote
+	}
+	cmd := m.openComposer(nil)
+	s := m.top().(*postingScreen)
+	s.quote = note
+	s.textarea.Placeholder = fmt.Sprintf("Quoting @%s...", note.User.Username)
+	return cmd
+}
+
+// closeComposer goes back to the screen s was opened from, cancelling
+// recipient lookups still in progress.
+func (m *model) closeComposer(s *postingScreen) {
+	if i := slices.Index(m.screens, screen(s)); i > 0 {
+		m.screens = m.screens[:i]
+	}
+	m.resetViewContext()
+}
+
+func (s *postingScreen) setSize(width, height int) {
+	h, _ := docStyle.GetFrameSize()
+	s.textarea.SetWidth(width - h - 4)
+	if s.poll != nil {
+		s.poll.setWidth(s.textarea.Width())
+	}
+	s.cwInput.Width = width - h - 4 - lipgloss.Width(s.cwInput.Prompt) - 1
+}
+
+// request builds the request to post the note.
+func (s *postingScreen) request() (misskey.CreateNoteRequest, error) {
+	req := misskey.CreateNoteRequest{
+		Text:       s.textarea.Value(),
+		CW:         strings.TrimSpace(s.cwInput.Value()),
+		Visibility: s.visibility,
+		LocalOnly:  s.localOnly,
+	}
+	if s.replyTo != nil {
+		req.ReplyID = s.replyTo.ID
+	}
+	if s.quote != nil {
+		req.RenoteID = s.quote.ID
+	}
+	for _, file := range s.attachments {
+		req.FileIDs = append(req.FileIDs, file.ID)
+	}
+	if s.poll != nil {
+		poll, err := s.poll.request()
+		if err != nil {
+			return req, err
+		}
+		req.Poll = poll
+	}
+	if s.visibility == misskey.VisibilitySpecified {
+		for _, user := range s.recipients {
+			req.VisibleUserIDs = append(req.VisibleUserIDs, user.ID)
+		}
+	}
+	if s.channel != nil {
+		req.ChannelID = s.channel.ID
+	}
+	return req, nil
+}
+
+func (s *postingScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.PostSubmit):
+			req, err := s.request()
+			if err != nil {
+				m.statusMessage = fmt.Sprintf("Cannot post: %s", err)
+				return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+			}
+			m.loading = true
+			return tea.Batch(m.spinner.Tick, m.createNoteCmd(s, req))
+		case key.Matches(msg, m.keys.PostCancel):
+			m.closeComposer(s)
+			return nil
+		case key.Matches(msg, m.keys.PostFocus):
+			if s.cwInput.Focused() {
+				s.cwInput.Blur()
+				return s.textarea.Focus()
+			}
+			s.textarea.Blur()
+			return s.cwInput.Focus()
+		case key.Matches(msg, m.keys.PostVisibility):
+			i := slices.Index(visibilities, s.visibility)
+			s.visibility = visibilities[(i+1)%len(visibilities)]
+			return nil
+		case key.Matches(msg, m.keys.PostLocalOnly):
+			s.localOnly = !s.localOnly
+			return nil
+		case key.Matches(msg, m.keys.PostRecipients):
+			s.visibility = misskey.VisibilitySpecified
+			rs := newRecipientsScreen(m, s)
+			m.push(rs)
+			return rs.input.Focus()
+		case key.Matches(msg, m.keys.PostPoll):
+			if s.poll == nil {
+				s.poll = newPollEditor(s.textarea.Width())
+			}
+			m.push(&pollScreen{composer: s})
+			s.textarea.Blur()
+			s.cwInput.Blur()
+			return s.poll.setFocus(0)
+		case key.Matches(msg, m.keys.PostAttach):
+			as := newAttachmentsScreen(m, s)
+			m.push(as)
+			s.textarea.Blur()
+			s.cwInput.Blur()
+			return as.edit("path")
+		}
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	if s.cwInput.Focused() {
+		s.cwInput, cmd = s.cwInput.Update(msg)
+	} else {
+		s.textarea, cmd = s.textarea.Update(msg)
+	}
+	cmds = append(cmds, cmd)
+	m.help, cmd = m.help.Update(msg)
+	cmds = append(cmds, cmd)
+	return tea.Batch(cmds...)
+}
+
+func (s *postingScreen) View(m *model) string {
+	var viewContent strings.Builder
+	if s.replyTo != nil {
+		quoteAuthor := fmt.Sprintf("@%s", s.replyTo.User.Username)
+		quoteText := renderNoteText(s.replyTo, max(s.textarea.Width(), 0), false)
+		quote := fmt.Sprintf("%s\n%s\n%s", quoteAuthor, quoteText, s.visibilityView())
+		viewContent.WriteString(quoteBoxStyle.Render(quote))
+		viewContent.WriteString("\n")
+	} else {
+		viewContent.WriteString(s.visibilityView())
+		viewContent.WriteString("\n")
+	}
+	if s.quote != nil {
+		viewContent.WriteString(metadataStyle.Render("Quoting"))
+		viewContent.WriteString("\n")
+		viewContent.WriteString(renderQuote(s.quote, max(s.textarea.Width(), 0)))
+		viewContent.WriteString("\n")
+	}
+	viewContent.WriteString(s.cwInput.View())
+	viewContent.WriteString("\n")
+	viewContent.WriteString(s.textarea.View())
+	if s.poll != nil {
+		viewContent.WriteString("\n")
+		viewContent.WriteString(metadataStyle.Render(s.poll.summary()))
+	}
+	if len(s.attachments) > 0 {
+		var names []string
+		for _, file := range s.attachments {
+			names = append(names, file.Name)
+		}
+		viewContent.WriteString("\n")
+		viewContent.WriteString(metadataStyle.Render("Attachments: " + strings.Join(names, ", ")))
+	}
+	if m.statusMessage != "" {
+		viewContent.WriteString("\n\n")
+		viewContent.WriteString(statusMessageStyle.Render(m.statusMessage))
+	}
+	viewContent.WriteString("\n\n")
+	viewContent.WriteString(m.help.View(m.keys))
+	dialog := dialogBoxStyle.Render(viewContent.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// visibilityView describes who will see the note being composed.
+func (s *postingScreen) visibilityView() string {
+	parts := []string{metadataStyle.Render("Visibility: ") + visibilityStyle.Render(s.visibility)}
+	if s.channel != nil {
+		// Channel notes are always public within the channel.
+		parts = []string{metadataStyle.Render("Channel: ") + visibilityStyle.Render(s.channel.Name)}
+	}
+	if s.localOnly {
+		parts = append(parts, visibilityStyle.Render("local only"))
+	}
+	if s.visibility == misskey.VisibilitySpecified {
+		var names []string
+		for _, user := range s.recipients {
+			names = append(names, "@"+acct(user))
+		}
+		if len(names) == 0 {
+			names = append(names, "only you")
+		}
+		parts = append(parts, metadataStyle.Render("To: "+strings.Join(names, ", ")))
+	}
+	return strings.Join(parts, metadataStyle.Render(" · "))
+}
+
+func (s *postingScreen) attachmentItems() []list.Item {
+	items := make([]list.Item, len(s.attachments))
+	for i, file := range s.attachments {
+		items[i] = driveFileItem{file: file}
+	}
+	return items
+}
+
+func (s *postingScreen) isAttached(fileID string) bool {
+	return slices.ContainsFunc(s.attachments, func(f misskey.DriveFile) bool { return f.ID == fileID })
+}
+
+func (s *postingScreen) detachFile(fileID string) {
+	s.attachments = slices.DeleteFunc(s.attachments, func(f misskey.DriveFile) bool { return f.ID == fileID })
+}
+
+// recipientItems lists users for the recipient picker: the search results,
+// or the current recipients when there are none.
+func (s *postingScreen) recipientItems(users []misskey.User) []list.Item {
+	if users == nil {
+		users = s.recipients
+	}
+	items := make([]list.Item, len(users))
+	for i, user := range users {
+		items[i] = userItem{user: user, selected: s.isRecipient(user.ID)}
+	}
+	return items
+}
+
+func (s *postingScreen) isRecipient(userID string) bool {
+	return slices.ContainsFunc(s.recipients, func(u misskey.User) bool { return u.ID == userID })
+}
+
+func (s *postingScreen) toggleRecipient(user misskey.User) {
+	if s.isRecipient(user.ID) {
+		s.recipients = slices.DeleteFunc(s.recipients, func(u misskey.User) bool { return u.ID == user.ID })
+		return
+	}
+	s.recipients = append(s.recipients, user)
+}
+
+type pollScreen struct {
+	composer *postingScreen
+}
+
+func (s *pollScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	poll := s.composer.poll
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.PollDone):
+			m.pop()
+			poll.field(poll.focus).Blur()
+			return s.composer.textarea.Focus()
+		case key.Matches(msg, m.keys.PollRemove):
+			m.pop()
+			s.composer.poll = nil
+			return s.composer.textarea.Focus()
+		case key.Matches(msg, m.keys.PollNext):
+			return poll.setFocus(poll.focus + 1)
+		case key.Matches(msg, m.keys.PollPrev):
+			return poll.setFocus(poll.focus - 1)
+		case key.Matches(msg, m.keys.PollAddChoice):
+			return poll.addChoice()
+		case key.Matches(msg, m.keys.PollRemoveChoice):
+			return poll.removeChoice()
+		case key.Matches(msg, m.keys.PollMultiple):
+			poll.multiple = !poll.multiple
+			return nil
+		}
+	}
+
+	return poll.update(msg)
+}
+
+func (s *pollScreen) View(m *model) string {
+	var viewContent strings.Builder
+	viewContent.WriteString(lipgloss.NewStyle().Bold(true).Render("Poll"))
+	viewContent.WriteString("\n\n")
+	viewContent.WriteString(s.composer.poll.view())
+	viewContent.WriteString("\n\n")
+	viewContent.WriteString(m.help.FullHelpView([][]key.Binding{
+		{m.keys.PollNext, m.keys.PollPrev, m.keys.PollDone},
+		{m.keys.PollAddChoice, m.keys.PollRemoveChoice, m.keys.PollMultiple, m.keys.PollRemove},
+	}))
+	dialog := dialogBoxStyle.Render(viewContent.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
+
+// recipientsScreen picks the users a "specified" note is visible to.
+type recipientsScreen struct {
+	composer *postingScreen
+	input    textinput.Model
+	list     list.Model
+}
+
+func newRecipientsScreen(m *model, composer *postingScreen) *recipientsScreen {
+	input := textinput.New()
+	input.Prompt = "To: @"
+	input.Placeholder = "username@host"
+
+	l := newPickerList(m.keys.RecipientToggle, m.keys.RecipientFocus, m.keys.RecipientDone)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	l.SetStatusBarItemName("user", "users")
+	l.SetItems(composer.recipientItems(nil))
+
+	s := &recipientsScreen{composer: composer, input: input, list: l}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *recipientsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.input.Width = width - h - lipgloss.Width(s.input.Prompt) - 1
+	s.list.SetSize(width-h, height-v-6)
+}
+
+func (s *recipientsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.RecipientDone):
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.RecipientFocus):
+			if s.input.Focused() {
+				s.input.Blur()
+				return nil
+			}
+			return s.input.Focus()
+		case s.input.Focused() && msg.String() == "enter":
+			if strings.TrimSpace(s.input.Value()) == "" {
+				return nil
+			}
+			m.statusMessage = "Searching users..."
+			return m.searchRecipientsCmd(s, s.input.Value())
+		case !s.input.Focused() && key.Matches(msg, m.keys.RecipientToggle):
+			if selectedItem, ok := s.list.SelectedItem().(userItem); ok {
+				s.composer.toggleRecipient(selectedItem.user)
+				selectedItem.selected = !selectedItem.selected
+				return s.list.SetItem(s.list.Index(), selectedItem)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.input.Focused() {
+		s.input, cmd = s.input.Update(msg)
+	} else {
+		s.list, cmd = s.list.Update(msg)
+	}
+	return cmd
+}
+
+func (s *recipientsScreen) View(m *model) string {
+	header := activeTabStyle.Render("RECIPIENTS")
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		s.composer.visibilityView(),
+		s.input.View(),
+		"",
+		s.list.View(),
+	)
+	return header + "\n" + docStyle.Render(content) + "\n" + m.statusBarView()
+}
+
+// attachmentsScreen uploads files for the note being composed and edits
+// the attached files.
+type attachmentsScreen struct {
+	composer    *postingScreen
+	input       textinput.Model
+	list        list.Model
+	editing     string   // "path", or "comment" while editing alt text
+	pathMatches []string // candidates of the last path completion
+}
+
+func newAttachmentsScreen(m *model, composer *postingScreen) *attachmentsScreen {
+	l := newPickerList(m.keys.AttachSensitive, m.keys.AttachComment, m.keys.AttachRemove, m.keys.AttachFocus, m.keys.AttachDone)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	l.SetStatusBarItemName("attachment", "attachments")
+	l.SetItems(composer.attachmentItems())
+
+	s := &attachmentsScreen{composer: composer, input: textinput.New(), list: l}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *attachmentsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.input.Width = width - h - 12
+	s.list.SetSize(width-h, height-v-8)
+}
+
+// edit switches the input between entering a path to upload and editing
+// the alt text of the selected attachment.
+func (s *attachmentsScreen) edit(editing string) tea.Cmd {
+	s.editing = editing
+	s.input.Reset()
+	s.pathMatches = nil
+	if editing == "comment" {
+		s.input.Prompt = "Alt text: "
+		s.input.Placeholder = "Describe the file"
+	} else {
+		s.input.Prompt = "Path: "
+		s.input.Placeholder = "~/Pictures/photo.png"
+	}
+	return s.input.Focus()
+}
+
+func (s *attachmentsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	c := s.composer
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if s.input.Focused() && s.editing == "comment" {
+			switch {
+			case key.Matches(msg, m.keys.AttachDone):
+				s.edit("path")
+				s.input.Blur()
+				return nil
+			case msg.String() == "enter":
+				var cmd tea.Cmd
+				if selectedItem, ok := s.list.SelectedItem().(driveFileItem); ok {
+					comment := s.input.Value()
+					cmd = m.updateDriveFileCmd(c, misskey.UpdateDriveFileRequest{FileID: selectedItem.file.ID, Comment: &comment})
+				}
+				s.edit("path")
+				s.input.Blur()
+				return cmd
+			}
+		} else {
+			switch {
+			case key.Matches(msg, m.keys.AttachDone):
+				m.pop()
+				return c.textarea.Focus()
+			case key.Matches(msg, m.keys.AttachFocus):
+				if s.input.Focused() {
+					s.input.Blur()
+					return nil
+				}
+				return s.input.Focus()
+			case key.Matches(msg, m.keys.AttachDrive):
+				ds := newDriveScreen(m, c)
+				m.push(ds)
+				s.input.Blur()
+				m.loading = true
+				return tea.Batch(m.spinner.Tick, m.fetchDriveFilesCmd(ds))
+			case s.input.Focused() && key.Matches(msg, m.keys.AttachComplete):
+				value, matches := completePath(s.input.Value())
+				s.input.SetValue(value)
+				s.input.CursorEnd()
+				s.pathMatches = nil
+				if len(matches) > 1 {
+					s.pathMatches = matches
+				}
+				return nil
+			case s.input.Focused() && key.Matches(msg, m.keys.AttachUpload):
+				path := strings.TrimSpace(s.input.Value())
+				if path == "" {
+					return nil
+				}
+				if len(c.attachments) >= maxAttachments {
+					m.statusMessage = fmt.Sprintf("A note can have at most %d files", maxAttachments)
+					return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+				}
+				s.input.Reset()
+				s.pathMatches = nil
+				m.statusMessage = fmt.Sprintf("Uploading %s...", filepath.Base(path))
+				return m.uploadFileCmd(c, expandHome(path))
+			case s.input.Focused():
+			case key.Matches(msg, m.keys.AttachSensitive):
+				if selectedItem, ok := s.list.SelectedItem().(driveFileItem); ok {
+					sensitive := !selectedItem.file.IsSensitive
+					return m.updateDriveFileCmd(c, misskey.UpdateDriveFileRequest{FileID: selectedItem.file.ID, IsSensitive: &sensitive})
+				}
+				return nil
+			case key.Matches(msg, m.keys.AttachComment):
+				if selectedItem, ok := s.list.SelectedItem().(driveFileItem); ok {
+					cmd := s.edit("comment")
+					s.input.SetValue(selectedItem.file.Comment)
+					return cmd
+				}
+				return nil
+			case key.Matches(msg, m.keys.AttachRemove):
+				if selectedItem, ok := s.list.SelectedItem().(driveFileItem); ok {
+					c.detachFile(selectedItem.file.ID)
+					s.list.SetItems(c.attachmentItems())
+				}
+				return nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.input.Focused() {
+		s.input, cmd = s.input.Update(msg)
+	} else {
+		s.list, cmd = s.list.Update(msg)
+	}
+	return cmd
+}
+
+func (s *attachmentsScreen) View(m *model) string {
+	header := activeTabStyle.Render("ATTACHMENTS")
+	var matches string
+	if len(s.pathMatches) > 0 {
+		matches = metadataStyle.Width(max(m.width-4, 0)).Render(strings.Join(s.pathMatches, "  "))
+	}
+	hint := metadataStyle.Render(fmt.Sprintf("%d/%d files · tab: complete · enter: upload · ctrl+o: pick from drive · shift+tab: edit attached files",
+		len(s.composer.attachments), maxAttachments))
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		s.input.View(),
+		matches,
+		hint,
+		"",
+		s.list.View(),
+	)
+	return header + "\n" + docStyle.Render(content) + "\n" + m.statusBarView()
+}
+
+// refreshAttachments shows the current attachments of composer in its
+// attachment screens.
+func (m *model) refreshAttachments(composer *postingScreen) {
+	for _, s := range m.screens {
+		if s, ok := s.(*attachmentsScreen); ok && s.composer == composer {
+			s.list.SetItems(composer.attachmentItems())
+		}
+	}
+}
+
+// driveScreen attaches files already in the drive.
+type driveScreen struct {
+	composer *postingScreen
+	list     list.Model
+}
+
+func newDriveScreen(m *model, composer *postingScreen) *driveScreen {
+	l := newPickerList(m.keys.DriveToggle, m.keys.DriveQuit)
+	l.DisableQuitKeybindings()
+	l.SetStatusBarItemName("file", "files")
+
+	s := &driveScreen{composer: composer, list: l}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *driveScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *driveScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	c := s.composer
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.DriveQuit) && s.list.FilterState() == list.Unfiltered:
+			m.pop()
+			m.refreshAttachments(c)
+			return nil
+		case key.Matches(msg, m.keys.DriveToggle):
+			if selectedItem, ok := s.list.SelectedItem().(driveFileItem); ok {
+				if selectedItem.selected {
+					c.detachFile(selectedItem.file.ID)
+				} else if len(c.attachments) < maxAttachments {
+					c.attachments = append(c.attachments, selectedItem.file)
+				} else {
+					m.statusMessage = fmt.Sprintf("A note can have at most %d files", maxAttachments)
+					return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+				}
+				selectedItem.selected = !selectedItem.selected
+				return s.list.SetItem(s.list.Index(), selectedItem)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *driveScreen) View(m *model) string {
+	header := activeTabStyle.Render("DRIVE")
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// detailScreen shows a note with the note it replies to and its replies.
+type detailScreen struct {
+	note       *misskey.Note // the note opened, which may be a pure renote
+	parent     *misskey.Note // the note it replies to, once loaded
+	replies    list.Model
+	viewport   viewport.Model
+	focus      string // "note", "replies"
+	cwExpanded bool   // the note's content warning is expanded
+	fileCursor int    // attachment of the note to open
+}
+
+func newDetailScreen(m *model, note *misskey.Note) *detailScreen {
+	replies := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	replies.SetShowTitle(false)
+	replies.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keys.Detail,
+			m.keys.DetailReply,
+			m.keys.DetailReact,
+			m.keys.DetailRenote,
+			m.keys.DetailQuote,
+			m.keys.DetailToggleCW,
+			m.keys.DetailVote,
+			m.keys.DetailNextFile,
+			m.keys.DetailOpenFile,
+			m.keys.DetailProfile,
+			m.keys.DetailMentions,
+			m.keys.ListUser,
+			m.keys.Thread,
+		}
+	}
+	s := &detailScreen{note: note, replies: replies, focus: "note"}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openDetail starts loading the detail view for note, which is shown on top
+// of the current screen once its replies have loaded.
+func (m *model) openDetail(note *misskey.Note) tea.Cmd {
+	m.resetViewContext()
+	m.loading = true
+	s := newDetailScreen(m, note)
+	m.opening = s
+
+	// Use target note for children/parent fetching (handle Renote)
+	targetNote := s.displayNote()
+
+	var batchCmds []tea.Cmd
+	batchCmds = append(batchCmds, m.spinner.Tick, m.fetchNoteChildrenCmd(s, targetNote.ID), s.loadImagesCmd(m))
+	if targetNote.ReplyId != "" {
+		batchCmds = append(batchCmds, m.fetchParentNoteCmd(s, targetNote.ReplyId))
+	}
+	return tea.Batch(batchCmds...)
+}
+
+func (s *detailScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.viewport.Width = width - h - 4
+	s.viewport.Height = (height - v) / 2
+	s.replies.SetSize(width-h, height-v-(s.viewport.Height+8))
+	s.viewport.SetContent(s.content())
+}
+
+// displayNote returns the note shown in the detail view: the note opened,
+// or the note it renotes for pure renotes.
+func (s *detailScreen) displayNote() *misskey.Note {
+	if s.note.Renote != nil && s.note.Text == "" {
+		return s.note.Renote
+	}
+	return s.note
+}
+
+func (s *detailScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.replies.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.DetailQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.DetailReply):
+			return m.openComposer(s.note)
+		case key.Matches(msg, m.keys.DetailToggleCW):
+			if noteCW(s.note) != "" {
+				s.cwExpanded = !s.cwExpanded
+				s.viewport.SetContent(s.content())
+			}
+			return nil
+		case key.Matches(msg, m.keys.DetailReact):
+			return m.openReactionPicker(s.note)
+		case key.Matches(msg, m.keys.DetailRenote):
+			return m.createRenoteCmd(s.note.ID)
+		case key.Matches(msg, m.keys.DetailQuote):
+			return m.openQuoteComposer(s.note)
+		case key.Matches(msg, m.keys.DetailNextFile):
+			if files := s.displayNote().Files; len(files) > 0 {
+				s.fileCursor = (s.fileCursor + 1) % len(files)
+				s.viewport.SetContent(s.content())
+			}
+			return s.loadImagesCmd(m)
+		case key.Matches(msg, m.keys.DetailOpenFile):
+			files := s.displayNote().Files
+			if len(files) == 0 {
+				return nil
+			}
+			file := files[min(s.fileCursor, len(files)-1)]
+			if err := openWith(m.config.Opener, file.URL); err != nil {
+				m.statusMessage = fmt.Sprintf("Failed to open %s: %v", file.Name, err)
+			} else {
+				m.statusMessage = fmt.Sprintf("Opening %s", file.Name)
+			}
+			return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		case key.Matches(msg, m.keys.DetailVote):
+			choice, _ := strconv.Atoi(msg.String())
+			return s.vote(m, choice-1)
+		case key.Matches(msg, m.keys.DetailProfile):
+			return m.openUserProfile(s.displayNote().User)
+		case key.Matches(msg, m.keys.ListUser):
+			return m.openListPicker(&s.displayNote().User)
+		case key.Matches(msg, m.keys.Thread):
+			return m.openThread(s.note)
+		case key.Matches(msg, m.keys.DetailMentions):
+			mentions := noteMentions(s.displayNote())
+			switch len(mentions) {
+			case 0:
+				m.statusMessage = "No mentions in this note"
+				return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+			case 1:
+				return m.mentionProfile(mentions[0])
+			}
+			m.push(newMentionsScreen(m, mentions))
+			return nil
+		case msg.String() == "tab":
+			if s.focus == "note" {
+				s.focus = "replies"
+			} else {
+				s.focus = "note"
+			}
+			return nil
+		case s.focus == "replies" && key.Matches(msg, m.keys.Detail):
+			if selectedItem, ok := s.replies.SelectedItem().(item); ok {
+				return m.openDetail(&selectedItem.note)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.focus == "note" {
+		s.viewport, cmd = s.viewport.Update(msg)
+	} else {
+		s.replies, cmd = s.replies.Update(msg)
+	}
+	return cmd
+}
+
+func (s *detailScreen) View(m *model) string {
+	// 1. Parent Note (if it exists)
+	var parentView string
+	if s.parent != nil {
+		parentAuthor := fmt.Sprintf("Replying to @%s", s.parent.User.Username)
+		parentInfo := metadataStyle.Render(parentAuthor)
+
+		textWidth := max(m.width-7, 0)
+		wrappedParentText := renderNoteText(s.parent, textWidth, false)
+
+		quote := fmt.Sprintf("%s\n%s", parentInfo, wrappedParentText)
+		parentView = quoteBoxStyle.Render(quote)
+	}
+
+	// 2. Main Note View
+	// Note Content View
+	var noteStyle lipgloss.Style
+	if s.focus == "note" {
+		noteStyle = focusedDetailContainerStyle
+	} else {
+		noteStyle = unfocusedDetailContainerStyle
+	}
+
+	mainNoteView := noteStyle.Render(s.viewport.View())
+	mediaView := s.mediaView(m)
+
+	// Calculate heights and set list height
+	status := m.statusBarView()
+	parentHeight := lipgloss.Height(parentView)
+	mediaHeight := 0
+	if mediaView != "" {
+		mediaHeight = lipgloss.Height(mediaView)
+	}
+	mainNoteHeight := lipgloss.Height(mainNoteView)
+
+	repliesHeaderStr := "Replies"
+	if s.focus == "replies" {
+		repliesHeaderStr = "Replies (Focused)"
+	}
+	repliesHeader := repliesHeaderStyle.Render(repliesHeaderStr)
+	repliesHeaderHeight := lipgloss.Height(repliesHeader)
+
+	statusHeight := lipgloss.Height(status)
+	listHeight := max(m.height-parentHeight-mediaHeight-mainNoteHeight-repliesHeaderHeight-statusHeight, 0)
+	s.replies.SetHeight(listHeight)
+
+	// 3. Replies
+	repliesView := lipgloss.JoinVertical(lipgloss.Left,
+		repliesHeader,
+		s.replies.View(),
+	)
+
+	// 4. Join them all together
+	finalView := lipgloss.JoinVertical(lipgloss.Left,
+		parentView,
+		mediaView,
+		mainNoteView,
+		repliesView,
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Left, docStyle.Render(finalView), status)
+}
+
+// content renders the note for the detail viewport.
+func (s *detailScreen) content() string {
+	displayNote := s.displayNote()
+	width := s.viewport.Width
+
+	var noteContent strings.Builder
+	if s.note.Renote != nil && s.note.Text == "" {
+		renoterName := s.note.User.Name
+		if renoterName == "" {
+			renoterName = s.note.User.Username
+		}
+		noteContent.WriteString(metadataStyle.Render(fmt.Sprintf("Renoted by %s", renoterName)))
+		noteContent.WriteString("\n")
+	}
+
+	noteContent.WriteString(lipgloss.NewStyle().Bold(true).Render(item{note: *displayNote}.Title()))
+	noteContent.WriteString("\n\n")
+	noteContent.WriteString(renderNoteText(displayNote, width, s.cwExpanded))
+	if displayNote.Poll != nil && (displayNote.CW == "" || s.cwExpanded) {
+		noteContent.WriteString("\n\n")
+		noteContent.WriteString(renderPoll(displayNote.Poll, width))
+	}
+	if len(displayNote.Files) > 0 {
+		noteContent.WriteString("\n\n")
+		noteContent.WriteString(renderFiles(displayNote.Files, s.fileCursor, width))
+	}
+	noteContent.WriteString("\n\n")
+
+	// Metadata
+	var reactions []string
+	for _, r := range slices.Sorted(maps.Keys(displayNote.Reactions)) {
+		reaction := fmt.Sprintf("%s %d", reactionLabel(r), displayNote.Reactions[r])
+		if r == displayNote.MyReaction {
+			reaction = myReactionStyle.Render(reaction)
+		}
+		reactions = append(reactions, reaction)
+	}
+	reactionsStr := strings.Join(reactions, " | ")
+
+	t, err := time.Parse(time.RFC3339, displayNote.CreatedAt)
+	var timeStr string
+	if err == nil {
+		timeStr = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	countsStr := fmt.Sprintf("Replies: %d, Renotes: %d", displayNote.RepliesCount, displayNote.RenoteCount)
+
+	metaData := lipgloss.JoinVertical(lipgloss.Left,
+		reactionsStr,
+		metadataStyle.Render(countsStr),
+		metadataStyle.Render(timeStr),
+	)
+	noteContent.WriteString(metaData)
+
+	return noteContent.String()
+}
+
+// mediaView shows the author's avatar and a preview of the selected
+// attachment above the note. All images end on the last line, so changing
+// any of them redraws them all.
+func (s *detailScreen) mediaView(m *model) string {
+	if m.graphics == graphicsNone {
+		return ""
+	}
+	note := s.displayNote()
+	rows := avatarRows
+	avatarCols := avatarRows * m.cell.height / m.cell.width
+	var preview string
+	if len(note.Files) > 0 {
+		rows = previewRows
+		file := note.Files[min(s.fileCursor, len(note.Files)-1)]
+		cols := min(m.width-avatarCols-8, 2*previewRows*m.cell.height/m.cell.width)
+		text := ""
+		if file.IsSensitive {
+			text = "Sensitive (o to open)"
+		}
+		if cols > 0 {
+			preview = m.imageView(previewKey(file), previewImageID, cols, rows, text)
+		}
+	}
+	avatar := m.imageView(note.User.AvatarURL, avatarImageID, avatarCols, rows, "")
+
+	media := lipgloss.JoinHorizontal(lipgloss.Top, avatar, "  ", preview)
+	if m.graphics == graphicsKitty {
+		i := strings.LastIndex(media, "\n") + 1
+		media = media[:i] + kittyDeleteAll + media[i:]
+	}
+	return media
+}
+
+// loadImagesCmd loads the author's avatar and a preview of the selected
+// attachment of the note. Sensitive files are only shown when opened.
+func (s *detailScreen) loadImagesCmd(m *model) tea.Cmd {
+	note := s.displayNote()
+	cmds := []tea.Cmd{m.loadImageCmd(note.User.AvatarURL, note.User.AvatarURL)}
+	if len(note.Files) > 0 {
+		file := note.Files[min(s.fileCursor, len(note.Files)-1)]
+		if !file.IsSensitive {
+			cmds = append(cmds, m.loadImageCmd(previewKey(file), previewURLs(file)...))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// vote votes for choice (0-based) in the note's poll, unless the poll has
+// ended or the user can't vote for it again.
+func (s *detailScreen) vote(m *model, choice int) tea.Cmd {
+	note := s.displayNote()
+	poll := note.Poll
+	if poll == nil || choice >= len(poll.Choices) {
+		return nil
+	}
+
+	switch {
+	case pollExpired(poll):
+		m.statusMessage = "The poll has ended"
+	case poll.Choices[choice].IsVoted,
+		!poll.Multiple && slices.ContainsFunc(poll.Choices, func(c misskey.PollChoice) bool { return c.IsVoted }):
+		m.statusMessage = "You have already voted"
+	default:
+		return m.voteCmd(note.ID, choice)
+	}
+	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+var apiErrorHints = map[string]string{
+	misskey.ErrRateLimitExceeded:    "Rate limit exceeded. Wait a moment and try again.",
+	misskey.ErrCredentialRequired:   "The access token is missing. Check config.json.",
+	misskey.ErrAuthenticationFailed: "The access token was rejected. Check config.json.",
+	misskey.ErrPermissionDenied:     "The access token lacks the permission needed for this action.",
+	misskey.ErrNoSuchNote:           "The note no longer exists.",
+	misskey.ErrAlreadyVoted:         "You have already voted in this poll.",
+	misskey.ErrAlreadyExpired:       "The poll has ended.",
+	misskey.ErrNoSuchUser:           "The user does not exist.",
+	misskey.ErrAlreadyFollowing:     "You are already following this user.",
+	misskey.ErrBlocking:             "You are blocking this user.",
+	misskey.ErrBlocked:              "This user has blocked you.",
+	misskey.ErrUnavailable:          "This feature is disabled on the server.",
+	misskey.ErrNoSuchObject:         "Nothing was found at that address.",
+	misskey.ErrNoSuchChannel:        "The channel no longer exists.",
+	misskey.ErrNoSuchList:           "The list no longer exists.",
+	misskey.ErrAlreadyAdded:         "The user is already in this list.",
+	misskey.ErrTooManyUsers:         "The list is full.",
+}
+
+// abandoned reports whether err is from a request cancelled by leaving the
+// view it was made for. Such requests are not errors.
+func abandoned(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+// describeError returns a short, human readable description of err suitable
+// for the status bar.
+func describeError(err error) string {
+	var apiErr *misskey.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	if hint, ok := apiErrorHints[apiErr.Code]; ok {
+		return hint
+	}
+	if apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return apiErr.Error()
+}
+
+func (m *model) errorView() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nAn error occurred: %s\n", describeError(m.err))
+
+	var apiErr *misskey.APIError
+	if errors.As(m.err, &apiErr) {
+		b.WriteString("\n")
+		if apiErr.Code != "" {
+			b.WriteString(metadataStyle.Render(fmt.Sprintf("  Code:     %s", apiErr.Code)) + "\n")
+		}
+		if apiErr.Message != "" {
+			b.WriteString(metadataStyle.Render(fmt.Sprintf("  Message:  %s", apiErr.Message)) + "\n")
+		}
+		b.WriteString(metadataStyle.Render(fmt.Sprintf("  Endpoint: %s", apiErr.Endpoint)) + "\n")
+		b.WriteString(metadataStyle.Render(fmt.Sprintf("  Status:   %s", apiErr.Status)) + "\n")
+		if apiErr.ID != "" {
+			b.WriteString(metadataStyle.Render(fmt.Sprintf("  ID:       %s", apiErr.ID)) + "\n")
+		}
+	}
+
+	b.WriteString("\nPress any key to return.")
+	return b.String()
+}
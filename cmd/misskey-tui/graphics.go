@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// graphicsProtocol is how images are drawn in the terminal.
+type graphicsProtocol string
+
+const (
+	graphicsNone       graphicsProtocol = "none"
+	graphicsHalfBlocks graphicsProtocol = "halfblocks"
+	graphicsSixel      graphicsProtocol = "sixel"
+	graphicsKitty      graphicsProtocol = "kitty"
+	graphicsITerm2     graphicsProtocol = "iterm2"
+)
+
+// kittyDeleteAll removes every image placed with the Kitty protocol.
+const kittyDeleteAll = "\x1b_Ga=d,d=a,q=2\x1b\\"
+
+var sixelTerminals = []string{"foot", "foot-extra", "mlterm", "yaft-256color", "contour"}
+
+// detectGraphics returns the configured protocol, or guesses one from the
+// environment when configured is empty or "auto". Terminals that are not
+// known to support a graphics protocol get half-block art.
+func detectGraphics(configured string) graphicsProtocol {
+	switch p := graphicsProtocol(configured); p {
+	case graphicsNone, graphicsHalfBlocks, graphicsSixel, graphicsKitty, graphicsITerm2:
+		return p
+	}
+
+	term, program := os.Getenv("TERM"), os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("TMUX") != "" || strings.HasPrefix(term, "screen"):
+		// Multiplexers need passthrough wrapping for escape-based graphics.
+		return graphicsHalfBlocks
+	case os.Getenv("KITTY_WINDOW_ID") != "" || term == "xterm-kitty" || program == "ghostty":
+		return graphicsKitty
+	case program == "iTerm.app" || program == "WezTerm" || os.Getenv("LC_TERMINAL") == "iTerm2":
+		return graphicsITerm2
+	case slices.Contains(sixelTerminals, term) || slices.Contains(sixelTerminals, program) || strings.Contains(term, "sixel"):
+		return graphicsSixel
+	}
+	return graphicsHalfBlocks
+}
+
+// imageBlock renders img as a block of exactly cols x rows cells. With an
+// escape-based protocol the block is blank and the image is drawn over it
+// from the end of its last line, so that the padding is always written
+// before the image when the renderer redraws those lines.
+func imageBlock(protocol graphicsProtocol, img image.Image, id uint32, cols, rows int, cell cellSize) string {
+	fitCols, fitRows := fitCells(img.Bounds().Dx(), img.Bounds().Dy(), cols, rows, cell)
+
+	lines := make([]string, rows)
+	blank := strings.Repeat(" ", cols)
+	for i := range lines {
+		lines[i] = blank
+	}
+
+	if protocol == graphicsHalfBlocks {
+		art := strings.Split(renderHalfBlocks(scaleImage(img, fitCols, fitRows*2)), "\n")
+		for i, line := range art {
+			lines[i] = line + strings.Repeat(" ", cols-fitCols)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	var seq string
+	switch protocol {
+	case graphicsKitty:
+		seq = encodeKitty(img, id, fitCols, fitRows)
+	case graphicsITerm2:
+		seq = encodeITerm2(img, fitCols, fitRows)
+	case graphicsSixel:
+		seq = encodeSixel(scaleImage(img, fitCols*cell.width, fitRows*cell.height))
+	}
+	// Save the cursor, move to the top left of the block, draw, restore.
+	move := fmt.Sprintf("\x1b[%dD", cols)
+	if rows > 1 {
+		move = fmt.Sprintf("\x1b[%dA", rows-1) + move
+	}
+	lines[rows-1] += "\x1b7" + move + seq + "\x1b8"
+	return strings.Join(lines, "\n")
+}
+
+// fitCells returns the number of cells an image of w x h pixels covers when
+// scaled to fit in cols x rows cells, keeping its aspect ratio.
+func fitCells(w, h, cols, rows int, cell cellSize) (int, int) {
+	if w <= 0 || h <= 0 {
+		return 1, 1
+	}
+	maxW, maxH := cols*cell.width, rows*cell.height
+	scale := min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	fitW := max(int(float64(w)*scale/float64(cell.width)+0.5), 1)
+	fitH := max(int(float64(h)*scale/float64(cell.height)+0.5), 1)
+	return min(fitW, cols), min(fitH, rows)
+}
+
+// scaleImage resizes src to w x h pixels, averaging the source pixels each
+// destination pixel covers.
+func scaleImage(src image.Image, w, h int) *image.RGBA {
+	w, h = max(w, 1), max(h, 1)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	for y := range h {
+		y0 := b.Min.Y + y*sh/h
+		y1 := max(b.Min.Y+(y+1)*sh/h, y0+1)
+		for x := range w {
+			x0 := b.Min.X + x*sw/w
+			x1 := max(b.Min.X+(x+1)*sw/w, x0+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// renderHalfBlocks draws img with "▀" characters, two pixels per cell.
+func renderHalfBlocks(img *image.RGBA) string {
+	b := img.Bounds()
+	var out strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		if y > b.Min.Y {
+			out.WriteString("\n")
+		}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			style := lipgloss.NewStyle().Foreground(hexColor(img.RGBAAt(x, y)))
+			if y+1 < b.Max.Y {
+				style = style.Background(hexColor(img.RGBAAt(x, y+1)))
+			}
+			out.WriteString(style.Render("▀"))
+		}
+	}
+	return out.String()
+}
+
+func hexColor(c color.RGBA) lipgloss.Color {
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+}
+
+// encodeKitty draws img with the Kitty graphics protocol, scaled by the
+// terminal to cols x rows cells. Reusing id and a fixed placement replaces
+// an earlier placement of the same image instead of adding one.
+func encodeKitty(img image.Image, id uint32, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunkSize = 4096
+	var out strings.Builder
+	for i := 0; i < len(data); i += chunkSize {
+		chunk := data[i:min(i+chunkSize, len(data))]
+		more := 0
+		if i+chunkSize < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&out, "\x1b_Ga=T,f=100,i=%d,p=1,c=%d,r=%d,C=1,q=2,m=%d;%s\x1b\\", id, cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return out.String()
+}
+
+// encodeITerm2 draws img with the iTerm2 inline image protocol, scaled by
+// the terminal to cols x rows cells.
+func encodeITerm2(img image.Image, cols, rows int) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		buf.Len(), cols, rows, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// encodeSixel draws img as Sixel graphics at its pixel size, using the 216
+// colors of a 6x6x6 color cube.
+func encodeSixel(img *image.RGBA) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// Palette index of every pixel; -1 for transparent pixels.
+	indexes := make([]int, w*h)
+	used := make([]bool, 216)
+	for y := range h {
+		for x := range w {
+			c := img.RGBAAt(b.Min.X+x, b.Min.Y+y)
+			if c.A < 128 {
+				indexes[y*w+x] = -1
+				continue
+			}
+			i := (int(c.R)*5+127)/255*36 + (int(c.G)*5+127)/255*6 + (int(c.B)*5+127)/255
+			indexes[y*w+x] = i
+			used[i] = true
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i, ok := range used {
+		if ok {
+			fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+		}
+	}
+
+	row := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		first := true
+		for i, ok := range used {
+			if !ok {
+				continue
+			}
+			present := false
+			for x := range w {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < h; dy++ {
+					if indexes[(band+dy)*w+x] == i {
+						bits |= 1 << dy
+					}
+				}
+				row[x] = '?' + bits
+				present = present || bits != 0
+			}
+			if !present {
+				continue
+			}
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(&out, "#%d", i)
+			writeSixelRun(&out, row)
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// writeSixelRun writes a row of sixel characters with run-length encoding.
+func writeSixelRun(out *strings.Builder, row []byte) {
+	for x := 0; x < len(row); {
+		n := 1
+		for x+n < len(row) && row[x+n] == row[x] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, row[x])
+		} else {
+			out.Write(bytes.Repeat(row[x:x+1], n))
+		}
+		x += n
+	}
+}
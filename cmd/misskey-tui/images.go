@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+const (
+	// maxImageBytes caps how much is downloaded for a single preview.
+	maxImageBytes = 8 << 20
+	// maxDecodePixels caps the dimensions of the images decoded, since a
+	// small file can declare a huge image.
+	maxDecodePixels = 24 << 20
+	// maxImagePixels caps the size of the images kept in the cache; previews
+	// never need more.
+	maxImagePixels = 512
+	// maxMemoryImageBytes and maxBlockBytes cap the decoded images and the
+	// rendered blocks kept in memory; the least recently used go first.
+	maxMemoryImageBytes = 64 << 20
+	maxBlockBytes       = 32 << 20
+	// maxDiskCacheBytes caps the on-disk cache; the least recently used
+	// files are removed once it is exceeded.
+	maxDiskCacheBytes = 256 << 20
+	// maxImageErrors caps the failures remembered; each is retried when the
+	// image is next shown once it is older than imageRetryAfter.
+	maxImageErrors  = 1024
+	imageRetryAfter = time.Minute
+
+	avatarRows  = 4
+	previewRows = 10
+)
+
+type cellSize struct{ width, height int }
+
+var defaultCellSize = cellSize{width: 10, height: 20}
+
+var errNoPreview = errors.New("no preview")
+
+// imageCache downloads images for previews, keeps them scaled down in
+// memory and in an on-disk cache, and remembers rendered blocks. All three
+// are capped in size.
+type imageCache struct {
+	mu        sync.Mutex
+	dir       string
+	diskBytes int64 // size of the on-disk cache, as of the last prune
+	client    *http.Client
+	images    *lru[image.Image]
+	errs      *lru[imageError]
+	loading   map[string]bool
+	blocks    *lru[string]
+}
+
+func newImageCache() *imageCache {
+	dir := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(cacheDir, "misskey-tui", "images")
+	}
+	c := &imageCache{
+		dir:     dir,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		images:  newLRU(maxMemoryImageBytes, imageBytes),
+		errs:    newLRU(maxImageErrors, func(imageError) int { return 1 }),
+		loading: map[string]bool{},
+		blocks:  newLRU(maxBlockBytes, func(block string) int { return len(block) }),
+	}
+	if dir != "" {
+		go c.pruneDisk()
+	}
+	return c
+}
+
+// imageError is a failure to load an image, kept so that it isn't retried on
+// every frame.
+type imageError struct {
+	err error
+	at  time.Time
+}
+
+// imageBytes estimates the memory img takes.
+func imageBytes(img image.Image) int {
+	b := img.Bounds()
+	return b.Dx() * b.Dy() * 4
+}
+
+// get returns the image for url if it has been loaded, and the error if
+// loading it failed.
+func (c *imageCache) get(url string) (image.Image, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	img, _ := c.images.get(url)
+	failure, _ := c.errs.get(url)
+	return img, failure.err
+}
+
+// startLoading reports whether url still needs to be loaded and marks it as
+// being loaded. Failures are tried again once they are imageRetryAfter old.
+func (c *imageCache) startLoading(url string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if url == "" || c.loading[url] || c.images.has(url) {
+		return false
+	}
+	if failure, ok := c.errs.get(url); ok && time.Since(failure.at) < imageRetryAfter {
+		return false
+	}
+	c.loading[url] = true
+	return true
+}
+
+// load fetches the first of urls that decodes, from the disk cache or the
+// network, and stores it under key.
+func (c *imageCache) load(ctx context.Context, key string, urls ...string) error {
+	var err error = errNoPreview
+	var img image.Image
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		if img, err = c.fetch(ctx, url); err == nil {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.loading, key)
+	if err != nil {
+		c.errs.put(key, imageError{err: err, at: time.Now()})
+		return err
+	}
+	c.errs.delete(key)
+	c.images.put(key, img)
+	return nil
+}
+
+func (c *imageCache) fetch(ctx context.Context, url string) (image.Image, error) {
+	path := c.path(url)
+	if path != "" {
+		if f, err := os.Open(path); err == nil {
+			img, err := png.Decode(f)
+			f.Close()
+			if err == nil {
+				// The modification time orders files for pruning.
+				now := time.Now()
+				os.Chtimes(path, now, now)
+				return img, nil
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	if resp.ContentLength > maxImageBytes {
+		return nil, fmt.Errorf("%s is too large to preview", url)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes))
+	if err != nil {
+		return nil, err
+	}
+	// Decoders for WebP and AVIF are not available; callers fall back to
+	// the next URL.
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxDecodePixels {
+		return nil, fmt.Errorf("%s is too large to preview", url)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	if scale := float64(maxImagePixels) / float64(max(b.Dx(), b.Dy())); scale < 1 {
+		img = scaleImage(img, int(float64(b.Dx())*scale), int(float64(b.Dy())*scale))
+	}
+	if path != "" {
+		c.save(path, img)
+	}
+	return img, nil
+}
+
+// save writes img to the disk cache; failures only cost a download later.
+func (c *imageCache) save(path string, img image.Image) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "tmp-*")
+	if err != nil {
+		return
+	}
+	err = png.Encode(f, img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.diskBytes += info.Size()
+	full := c.diskBytes > maxDiskCacheBytes
+	c.mu.Unlock()
+	if full {
+		c.pruneDisk()
+	}
+}
+
+// pruneDisk removes the least recently used files from the disk cache until
+// it fits in maxDiskCacheBytes.
+func (c *imageCache) pruneDisk() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	slices.SortFunc(files, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range files {
+		if total <= maxDiskCacheBytes {
+			break
+		}
+		if os.Remove(filepath.Join(c.dir, info.Name())) == nil {
+			total -= info.Size()
+		}
+	}
+
+	c.mu.Lock()
+	c.diskBytes = total
+	c.mu.Unlock()
+}
+
+func (c *imageCache) path(url string) string {
+	if c.dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".png")
+}
+
+// block renders the image for key as a cols x rows block, caching the result
+// since encoding is too slow to repeat on every frame.
+func (c *imageCache) block(protocol graphicsProtocol, key string, id uint32, cols, rows int, cell cellSize) (string, bool) {
+	img, _ := c.get(key)
+	if img == nil {
+		return "", false
+	}
+	blockKey := fmt.Sprintf("%s %s %d %d %d %d %d", protocol, key, id, cols, rows, cell.width, cell.height)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if block, ok := c.blocks.get(blockKey); ok {
+		return block, true
+	}
+	block := imageBlock(protocol, img, id, cols, rows, cell)
+	c.blocks.put(blockKey, block)
+	return block, true
+}
+
+// lru is a map that drops its least recently used entries once the total
+// size of its values exceeds max. It is not safe for concurrent use.
+type lru[V any] struct {
+	max     int
+	size    int
+	sizeOf  func(V) int
+	order   *list.List // of *lruEntry[V], most recently used first
+	entries map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+	size  int
+}
+
+func newLRU[V any](max int, sizeOf func(V) int) *lru[V] {
+	return &lru[V]{max: max, sizeOf: sizeOf, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (l *lru[V]) has(key string) bool {
+	_, ok := l.entries[key]
+	return ok
+}
+
+// get returns the value for key and marks it as recently used.
+func (l *lru[V]) get(key string) (V, bool) {
+	e, ok := l.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	l.order.MoveToFront(e)
+	return e.Value.(*lruEntry[V]).value, true
+}
+
+// put stores value under key, then evicts the least recently used entries
+// other than it while the cache is over its size.
+func (l *lru[V]) put(key string, value V) {
+	if e, ok := l.entries[key]; ok {
+		l.remove(e)
+	}
+	entry := &lruEntry[V]{key: key, value: value, size: l.sizeOf(value)}
+	l.entries[key] = l.order.PushFront(entry)
+	l.size += entry.size
+	for l.size > l.max && l.order.Len() > 1 {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *lru[V]) delete(key string) {
+	if e, ok := l.entries[key]; ok {
+		l.remove(e)
+	}
+}
+
+func (l *lru[V]) remove(e *list.Element) {
+	entry := l.order.Remove(e).(*lruEntry[V])
+	delete(l.entries, entry.key)
+	l.size -= entry.size
+}
+
+// previewKey is the image cache key of file's preview.
+func previewKey(file misskey.DriveFile) string {
+	return "file:" + file.ID
+}
+
+// previewURLs returns the URLs to try for a preview of file: its thumbnail,
+// then the file itself if it is a small image in a format we can decode.
+func previewURLs(file misskey.DriveFile) []string {
+	urls := []string{file.ThumbnailURL}
+	switch file.Type {
+	case "image/png", "image/jpeg", "image/gif":
+		if file.Size <= maxImageBytes {
+			urls = append(urls, file.URL)
+		}
+	}
+	return urls
+}
@@ -1,36 +1,38 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yulog/misskey-tui/misskey"
+)
 
 type item struct {
-	note Note
+	note     misskey.Note
+	expanded bool // show the text behind the content warning
 }
 
 func (i item) Title() string {
 	note := i.note
 	isRenote := note.Renote != nil && note.Text == ""
-	
-	username := note.User.Username
-	name := note.User.Name
-	
-	title := ""
-	if name != "" {
-		title = fmt.Sprintf("%s (@%s)", name, username)
-	} else {
-		title = fmt.Sprintf("@%s", username)
+
+	title := userTitle(note.User)
+	if badge := visibilityBadge(&note); badge != "" {
+		title += " " + badge
 	}
 
 	if isRenote {
 		return fmt.Sprintf("%s renoted", title)
 	}
+	if note.Renote != nil {
+		return fmt.Sprintf("%s quoted @%s", title, acct(note.Renote.User))
+	}
 	return title
 }
 
 func (i item) Description() string {
-	if i.note.Renote != nil && i.note.Text == "" {
-		return i.note.Renote.Text
-	}
-	return i.note.Text
+	return plainNoteText(&i.note, i.expanded)
 }
 
 func (i item) FilterValue() string {
@@ -39,3 +41,304 @@ func (i item) FilterValue() string {
 	}
 	return i.note.User.Username
 }
+
+func userTitle(user misskey.User) string {
+	if user.Name != "" {
+		return fmt.Sprintf("%s (@%s)", user.Name, acct(user))
+	}
+	return fmt.Sprintf("@%s", acct(user))
+}
+
+// acct returns "username" for local users and "username@host" for remote ones.
+func acct(user misskey.User) string {
+	if user.Host != "" {
+		return user.Username + "@" + user.Host
+	}
+	return user.Username
+}
+
+// parseAcct splits "@username@host" into its parts; the leading "@" is
+// optional and host is empty for "@username".
+func parseAcct(s string) (username, host string) {
+	username, host, _ = strings.Cut(strings.TrimPrefix(s, "@"), "@")
+	return username, host
+}
+
+// visibilityBadge marks notes that are not public or not federated.
+func visibilityBadge(note *misskey.Note) string {
+	var badges []string
+	if note.Visibility != "" && note.Visibility != misskey.VisibilityPublic {
+		badges = append(badges, "["+note.Visibility+"]")
+	}
+	if note.LocalOnly {
+		badges = append(badges, "[local only]")
+	}
+	return strings.Join(badges, " ")
+}
+
+// noteCW returns the content warning of the note as displayed, looking
+// through pure renotes.
+func noteCW(note *misskey.Note) string {
+	if note.Renote != nil && note.Text == "" {
+		return note.Renote.CW
+	}
+	return note.CW
+}
+
+// plainNoteText returns a one-line preview of the note, followed by the note
+// it quotes. Notes with a content warning only show the warning unless
+// expanded.
+func plainNoteText(note *misskey.Note, expanded bool) string {
+	if note.Renote != nil && note.Text == "" {
+		note = note.Renote
+	}
+	text := plainMFM(note.Text)
+	if note.Poll != nil {
+		text += fmt.Sprintf(" [poll: %d choices]", len(note.Poll.Choices))
+	}
+	if badge := filesBadge(note.Files); badge != "" {
+		text += " " + badge
+	}
+	if note.CW != "" {
+		if !expanded {
+			return strings.TrimSpace(fmt.Sprintf("CW: %s (%d characters hidden) %s", plainMFM(note.CW), len([]rune(note.Text)), filesBadge(note.Files)))
+		}
+		text = fmt.Sprintf("CW: %s | %s", plainMFM(note.CW), text)
+	}
+	if note.Renote != nil {
+		text += fmt.Sprintf(" » @%s: %s", acct(note.Renote.User), plainNoteText(note.Renote, false))
+	}
+	return text
+}
+
+// filesBadge summarises a note's attachments by kind, e.g. "[2 images, 1 video]".
+func filesBadge(files []misskey.DriveFile) string {
+	if len(files) == 0 {
+		return ""
+	}
+	kinds := []struct{ name, plural string }{
+		{"image", "images"},
+		{"video", "videos"},
+		{"audio", "audio"},
+		{"file", "files"},
+	}
+	counts := map[string]int{}
+	for _, file := range files {
+		kind, _, _ := strings.Cut(file.Type, "/")
+		switch kind {
+		case "image", "video", "audio":
+		default:
+			kind = "file"
+		}
+		counts[kind]++
+	}
+	var parts []string
+	for _, kind := range kinds {
+		switch n := counts[kind.name]; {
+		case n == 1:
+			parts = append(parts, "1 "+kind.name)
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind.plural))
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+type notificationItem struct {
+	notification misskey.Notification
+}
+
+func (i notificationItem) Title() string {
+	n := i.notification
+	who := "Someone"
+	if n.User != nil {
+		who = userTitle(*n.User)
+	}
+
+	switch n.Type {
+	case "reply":
+		return fmt.Sprintf("%s replied", who)
+	case "mention":
+		return fmt.Sprintf("%s mentioned you", who)
+	case "reaction":
+		return fmt.Sprintf("%s reacted %s", who, n.Reaction)
+	case "renote":
+		return fmt.Sprintf("%s renoted your note", who)
+	case "quote":
+		return fmt.Sprintf("%s quoted your note", who)
+	case "follow":
+		return fmt.Sprintf("%s followed you", who)
+	case "pollEnded":
+		return "A poll has ended"
+	default:
+		return fmt.Sprintf("%s: %s", n.Type, who)
+	}
+}
+
+func (i notificationItem) Description() string {
+	note := i.notification.Note
+	if note == nil {
+		return ""
+	}
+	return plainNoteText(note, false)
+}
+
+func (i notificationItem) FilterValue() string {
+	if i.notification.User != nil {
+		return i.notification.Type + " " + i.notification.User.Username
+	}
+	return i.notification.Type
+}
+
+type userItem struct {
+	user     misskey.User
+	selected bool
+}
+
+func (i userItem) Title() string { return userTitle(i.user) }
+
+func (i userItem) Description() string {
+	if i.selected {
+		return "✓ recipient"
+	}
+	return ""
+}
+
+func (i userItem) FilterValue() string { return i.user.Username + " " + i.user.Name }
+
+type driveFileItem struct {
+	file     misskey.DriveFile
+	selected bool
+}
+
+func (i driveFileItem) Title() string {
+	if i.selected {
+		return "✓ " + i.file.Name
+	}
+	return i.file.Name
+}
+
+func (i driveFileItem) Description() string {
+	parts := []string{i.file.Type, formatSize(i.file.Size)}
+	if i.file.IsSensitive {
+		parts = append(parts, "sensitive")
+	}
+	if i.file.Comment != "" {
+		parts = append(parts, "alt: "+i.file.Comment)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (i driveFileItem) FilterValue() string { return i.file.Name }
+
+type accountItem struct {
+	account *Account
+	current bool
+}
+
+func (i accountItem) Title() string {
+	if i.current {
+		return fmt.Sprintf("%s (current)", i.account.Name)
+	}
+	return i.account.Name
+}
+
+func (i accountItem) Description() string { return i.account.InstanceURL }
+
+func (i accountItem) FilterValue() string { return i.account.Name }
+
+type antennaItem struct {
+	antenna misskey.Antenna
+	current bool
+}
+
+func (i antennaItem) Title() string {
+	if i.current {
+		return fmt.Sprintf("%s (current)", i.antenna.Name)
+	}
+	return i.antenna.Name
+}
+
+// Description summarises where the antenna collects notes from and the
+// keywords it matches: spaces join keywords that must all appear, " | "
+// separates alternatives.
+func (i antennaItem) Description() string {
+	var keywords []string
+	for _, and := range i.antenna.Keywords {
+		if len(and) > 0 {
+			keywords = append(keywords, strings.Join(and, " "))
+		}
+	}
+	if len(keywords) == 0 {
+		return i.antenna.Src
+	}
+	return i.antenna.Src + " · " + strings.Join(keywords, " | ")
+}
+
+func (i antennaItem) FilterValue() string { return i.antenna.Name }
+
+type channelItem struct {
+	channel misskey.Channel
+}
+
+func (i channelItem) Title() string {
+	if i.channel.IsFollowing {
+		return "✓ " + i.channel.Name
+	}
+	return i.channel.Name
+}
+
+func (i channelItem) Description() string {
+	counts := fmt.Sprintf("%d users · %d notes", i.channel.UsersCount, i.channel.NotesCount)
+	if desc := plainMFM(i.channel.Description); desc != "" {
+		return counts + " · " + desc
+	}
+	return counts
+}
+
+func (i channelItem) FilterValue() string { return i.channel.Name }
+
+// userListItem is a list in the list picker. With a member set, the picker
+// adds member to lists or removes them instead of opening a list.
+type userListItem struct {
+	list    misskey.UserList
+	member  *misskey.User
+	current bool
+}
+
+func (i userListItem) Title() string {
+	switch {
+	case i.member != nil && slices.Contains(i.list.UserIDs, i.member.ID):
+		return "✓ " + i.list.Name
+	case i.current:
+		return fmt.Sprintf("%s (current)", i.list.Name)
+	}
+	return i.list.Name
+}
+
+func (i userListItem) Description() string {
+	return fmt.Sprintf("%d users", len(i.list.UserIDs))
+}
+
+func (i userListItem) FilterValue() string { return i.list.Name }
+
+type emojiItem struct {
+	emoji misskey.Emoji
+}
+
+func (i emojiItem) Title() string { return ":" + i.emoji.Name + ":" }
+
+func (i emojiItem) Description() string {
+	desc := i.emoji.Category
+	if len(i.emoji.Aliases) > 0 {
+		if desc != "" {
+			desc += " · "
+		}
+		desc += strings.Join(i.emoji.Aliases, ", ")
+	}
+	return desc
+}
+
+func (i emojiItem) FilterValue() string {
+	return i.emoji.Name + " " + strings.Join(i.emoji.Aliases, " ") + " " + i.emoji.Category
+}
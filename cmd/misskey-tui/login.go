@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// Permissions requested through MiAuth.
+var miauthPermissions = []string{
+	"read:account",
+	"write:account",
+	"read:notifications",
+	"write:notes",
+	"read:reactions",
+	"write:reactions",
+	"write:votes",
+	"read:following",
+	"write:following",
+	"read:drive",
+	"write:drive",
+	"read:channels",
+	"write:channels",
+}
+
+const miauthPollInterval = 2 * time.Second
+
+var errLoginCancelled = errors.New("login cancelled")
+
+// --- Messages ---
+
+type miauthPollMsg struct{}
+type miauthCheckedMsg struct {
+	result *misskey.MiAuthResult
+	err    error
+}
+
+// --- Login Model ---
+
+// loginModel runs the MiAuth flow and produces an Account.
+type loginModel struct {
+	input       textinput.Model
+	spinner     spinner.Model
+	state       string // "input", "waiting"
+	instanceURL string
+	session     string
+	authURL     string
+	status      string
+	account     *Account
+	ctx         context.Context
+	cancel      context.CancelFunc
+	width       int
+	height      int
+}
+
+func newLoginModel() *loginModel {
+	ti := textinput.New()
+	ti.Placeholder = "misskey.io"
+	ti.Prompt = "Instance URL: "
+	ti.Width = 40
+	ti.Focus()
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
+
+	return &loginModel{
+		input:   ti,
+		spinner: s,
+		state:   "input",
+	}
+}
+
+// runLogin asks for an instance URL and authorizes the client through MiAuth.
+func runLogin() (*Account, error) {
+	p := tea.NewProgram(newLoginModel(), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	lm := final.(*loginModel)
+	if lm.cancel != nil {
+		lm.cancel()
+	}
+	if lm.account == nil {
+		return nil, errLoginCancelled
+	}
+	return lm.account, nil
+}
+
+func normalizeInstanceURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("please enter your instance URL")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("%q is not a valid URL", raw)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+func (m *loginModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *loginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			if m.state == "waiting" {
+				m.cancel()
+				m.state = "input"
+				m.status = ""
+				return m, m.input.Focus()
+			}
+			return m, tea.Quit
+		case "enter":
+			if m.state == "input" {
+				return m, m.startSession()
+			}
+		case "o":
+			if m.state == "waiting" {
+				if err := openURL(m.authURL); err != nil {
+					m.status = fmt.Sprintf("Failed to open browser: %v", err)
+				}
+				return m, nil
+			}
+		}
+
+	case miauthPollMsg:
+		if m.state != "waiting" {
+			return m, nil
+		}
+		return m, m.checkCmd()
+
+	case miauthCheckedMsg:
+		if m.state != "waiting" || errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Waiting for authorization (%s)", describeError(msg.err))
+			return m, m.pollCmd()
+		}
+		if !msg.result.OK {
+			return m, m.pollCmd()
+		}
+
+		name := m.instanceURL
+		if u, err := url.Parse(m.instanceURL); err == nil {
+			name = u.Host
+		}
+		if msg.result.User != nil {
+			name = fmt.Sprintf("%s@%s", msg.result.User.Username, name)
+		}
+		m.account = &Account{Name: name, InstanceURL: m.instanceURL, AccessToken: msg.result.Token}
+		return m, tea.Quit
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+
+	if m.state == "input" {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *loginModel) startSession() tea.Cmd {
+	instanceURL, err := normalizeInstanceURL(m.input.Value())
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	session, err := misskey.NewMiAuthSession()
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	authURL, err := misskey.MiAuthURL(instanceURL, session, misskey.MiAuthOptions{
+		Name:        "misskey-tui",
+		Permissions: miauthPermissions,
+	})
+	if err != nil {
+		m.status = err.Error()
+		return nil
+	}
+
+	m.instanceURL = instanceURL
+	m.session = session
+	m.authURL = authURL
+	m.state = "waiting"
+	m.status = ""
+	m.input.Blur()
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	if err := openURL(authURL); err != nil {
+		m.status = "Could not open a browser; open the URL above manually."
+	}
+
+	return tea.Batch(m.spinner.Tick, m.pollCmd())
+}
+
+func (m *loginModel) pollCmd() tea.Cmd {
+	return tea.Tick(miauthPollInterval, func(time.Time) tea.Msg { return miauthPollMsg{} })
+}
+
+func (m *loginModel) checkCmd() tea.Cmd {
+	ctx, client, session := m.ctx, misskey.NewClient(m.instanceURL, ""), m.session
+	return func() tea.Msg {
+		result, err := client.CheckMiAuth(ctx, session)
+		return miauthCheckedMsg{result: result, err: err}
+	}
+}
+
+func (m *loginModel) View() string {
+	var content strings.Builder
+	content.WriteString(lipgloss.NewStyle().Bold(true).Render("Log in to Misskey"))
+	content.WriteString("\n\n")
+
+	switch m.state {
+	case "input":
+		content.WriteString(m.input.View())
+		content.WriteString("\n\n")
+		content.WriteString(metadataStyle.Render("enter: continue • esc: quit"))
+	case "waiting":
+		content.WriteString("Authorize misskey-tui in your browser:\n\n")
+		content.WriteString(lipgloss.NewStyle().Width(max(m.width-8, 20)).Render(m.authURL))
+		content.WriteString("\n\n")
+		content.WriteString(fmt.Sprintf("%s Waiting for authorization...", m.spinner.View()))
+		content.WriteString("\n\n")
+		content.WriteString(metadataStyle.Render("o: open in browser • esc: back"))
+	}
+
+	if m.status != "" {
+		content.WriteString("\n\n")
+		content.WriteString(statusMessageStyle.Render(m.status))
+	}
+
+	dialog := dialogBoxStyle.Padding(1, 2).Render(content.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+}
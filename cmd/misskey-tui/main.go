@@ -1,35 +1,68 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"net/http"
+	"io/fs"
 	"os"
-	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yulog/misskey-tui/misskey"
 )
 
 func main() {
+	accountName := flag.String("account", "", "name of the account to use (defaults to default_account in config.json)")
+	login := flag.Bool("login", false, "log in to another account and add it to config.json")
+	flag.Parse()
+
 	config, err := loadConfig()
+	if errors.Is(err, fs.ErrNotExist) {
+		config, err = &Config{}, nil
+	}
 	if err != nil {
 		fmt.Printf("Failed to load config.json: %v\nPlease make sure the file exists and is correct.", err)
 		os.Exit(1)
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	if len(config.Accounts) == 0 || *login {
+		account, err := runLogin()
+		if err != nil {
+			fmt.Printf("Login failed: %v\n", err)
+			os.Exit(1)
+		}
+		config.addAccount(*account)
+		if err := saveConfig(config); err != nil {
+			fmt.Printf("Failed to save config.json: %v\n", err)
+			os.Exit(1)
+		}
+		*accountName = account.Name
+	}
 
-	user, err := fetchMe(client, config)
+	account, err := config.account(*accountName)
 	if err != nil {
-		fmt.Printf("Failed to fetch user info: %v\n", err)
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	model := newModel(config, user)
-	model.client = client
+	client := misskey.NewClient(account.InstanceURL, account.AccessToken)
+
+	user, err := client.Me(context.Background())
+	if err != nil {
+		fmt.Printf("Failed to fetch user info: %s\n", describeError(err))
+		os.Exit(1)
+	}
+
+	model := newModel(config, account, client, user)
+	model.stream = newStream(client, model.streamTimelines()...)
+	go model.stream.run()
 
 	p := tea.NewProgram(&model, tea.WithAltScreen())
 
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	model.stream.close()
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
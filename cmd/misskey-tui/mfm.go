@@ -0,0 +1,141 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/mfm"
+)
+
+var mfmColorPattern = regexp.MustCompile(`^[0-9a-fA-F]{3}([0-9a-fA-F]{3})?$`)
+
+// renderMFM renders MFM text with terminal styling, wrapped to width.
+func renderMFM(text string, width int) string {
+	out := renderMFMNodes(mfm.Parse(text), lipgloss.NewStyle(), width)
+	if width <= 0 {
+		return out
+	}
+	return lipgloss.NewStyle().Width(width).Render(out)
+}
+
+// plainMFM renders MFM text as a single line of plain text, for list items.
+func plainMFM(text string) string {
+	return strings.Join(strings.Fields(mfm.PlainText(mfm.Parse(text))), " ")
+}
+
+func renderMFMNodes(nodes []mfm.Node, style lipgloss.Style, width int) string {
+	var b strings.Builder
+	for i, n := range nodes {
+		switch n.Kind {
+		case mfm.Quote, mfm.CodeBlock, mfm.Center:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString("\n")
+			}
+			b.WriteString(renderMFMBlock(n, style, width))
+			// Blocks end their line; don't double an explicit newline.
+			if i < len(nodes)-1 && !(nodes[i+1].Kind == mfm.Text && strings.HasPrefix(nodes[i+1].Value, "\n")) {
+				b.WriteString("\n")
+			}
+		default:
+			b.WriteString(renderMFMInline(n, style, width))
+		}
+	}
+	return b.String()
+}
+
+func renderMFMBlock(n mfm.Node, style lipgloss.Style, width int) string {
+	switch n.Kind {
+	case mfm.Quote:
+		inner := max(width-quoteBoxStyle.GetHorizontalFrameSize(), 1)
+		content := renderMFMNodes(n.Children, style, inner)
+		return quoteBoxStyle.Render(lipgloss.NewStyle().Width(inner).Render(content))
+	case mfm.CodeBlock:
+		return mfmCodeBlockStyle.Render(n.Value)
+	default: // mfm.Center
+		content := renderMFMNodes(n.Children, style, width)
+		if width <= 0 {
+			return content
+		}
+		return lipgloss.NewStyle().Width(width).Align(lipgloss.Center).Render(content)
+	}
+}
+
+func renderMFMInline(n mfm.Node, style lipgloss.Style, width int) string {
+	switch n.Kind {
+	case mfm.Text:
+		return styleLines(style, n.Value)
+	case mfm.Bold:
+		return renderMFMNodes(n.Children, style.Bold(true), width)
+	case mfm.Italic:
+		return renderMFMNodes(n.Children, style.Italic(true), width)
+	case mfm.Strike:
+		return renderMFMNodes(n.Children, style.Strikethrough(true), width)
+	case mfm.Small:
+		return renderMFMNodes(n.Children, style.Faint(true), width)
+	case mfm.InlineCode:
+		return mfmInlineCodeStyle.Inherit(style).Render(n.Value)
+	case mfm.Mention:
+		acct := "@" + n.Value
+		if n.Host != "" {
+			acct += "@" + n.Host
+		}
+		return mfmMentionStyle.Inherit(style).Render(acct)
+	case mfm.Hashtag:
+		return mfmHashtagStyle.Inherit(style).Render("#" + n.Value)
+	case mfm.Emoji:
+		return mfmEmojiStyle.Inherit(style).Render(":" + n.Value + ":")
+	case mfm.URL:
+		return hyperlink(n.Value, mfmLinkStyle.Inherit(style).Render(n.Value))
+	case mfm.Link:
+		label := hyperlink(n.Value, renderMFMNodes(n.Children, mfmLinkStyle.Inherit(style), width))
+		return label + metadataStyle.Render(" ("+n.Value+")")
+	case mfm.Fn:
+		return renderMFMNodes(n.Children, mfmFnStyle(n, style), width)
+	default:
+		return renderMFMNodes(n.Children, style, width)
+	}
+}
+
+// mfmFnStyle returns the style for the content of a $[fn ...] node. Only
+// functions with a terminal equivalent change the style; animations and
+// transforms fall back to plain text.
+func mfmFnStyle(n mfm.Node, style lipgloss.Style) lipgloss.Style {
+	switch n.Value {
+	case "fg":
+		if color := n.Args["color"]; mfmColorPattern.MatchString(color) {
+			return style.Foreground(lipgloss.Color("#" + expandHexColor(color)))
+		}
+	case "bg":
+		if color := n.Args["color"]; mfmColorPattern.MatchString(color) {
+			return style.Background(lipgloss.Color("#" + expandHexColor(color)))
+		}
+	case "x2", "x3", "x4":
+		return style.Bold(true)
+	}
+	return style
+}
+
+func expandHexColor(color string) string {
+	if len(color) != 3 {
+		return color
+	}
+	return string([]byte{color[0], color[0], color[1], color[1], color[2], color[2]})
+}
+
+// styleLines applies style to each line separately so that multi-line text
+// is not padded into a block.
+func styleLines(style lipgloss.Style, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hyperlink wraps text in an OSC 8 terminal hyperlink to url.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
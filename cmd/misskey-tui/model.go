@@ -1,49 +1,162 @@
 package main
 
 import (
-	"net/http"
-	"net/url"
-	"time"
+	"context"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textarea"
-	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yulog/misskey-tui/misskey"
 )
 
 // --- Keys ---
 
 type keyMap struct {
 	// For timeline
-	Post   key.Binding
-	Reply  key.Binding
-	React  key.Binding
-	Renote key.Binding
-	Detail key.Binding
-	Switch key.Binding
-	Quit   key.Binding
+	Post      key.Binding
+	Reply     key.Binding
+	React     key.Binding
+	Renote    key.Binding
+	Quote     key.Binding
+	Detail    key.Binding
+	Switch    key.Binding
+	LoadNewer key.Binding
+	Notify    key.Binding
+	Accounts  key.Binding
+	ToggleCW  key.Binding
+	Profile   key.Binding
+	Search    key.Binding
+	FindUser  key.Binding
+	Antennas  key.Binding
+	Channels  key.Binding
+	Lists     key.Binding
+	ListUser  key.Binding
+	Thread    key.Binding
+	Columns   key.Binding
+	AddColumn key.Binding
+	Quit      key.Binding
+
+	// While loading
+	LoadingCancel key.Binding
+	LoadingQuit   key.Binding
 
 	// For posting
-	PostSubmit key.Binding
-	PostCancel key.Binding
+	PostSubmit     key.Binding
+	PostCancel     key.Binding
+	PostFocus      key.Binding
+	PostVisibility key.Binding
+	PostLocalOnly  key.Binding
+	PostRecipients key.Binding
+	PostPoll       key.Binding
+	PostAttach     key.Binding
+
+	// For attachments
+	AttachUpload    key.Binding
+	AttachComplete  key.Binding
+	AttachFocus     key.Binding
+	AttachDrive     key.Binding
+	AttachSensitive key.Binding
+	AttachComment   key.Binding
+	AttachRemove    key.Binding
+	AttachDone      key.Binding
+
+	// For drive picker
+	DriveToggle key.Binding
+	DriveQuit   key.Binding
+
+	// For poll editor
+	PollNext         key.Binding
+	PollPrev         key.Binding
+	PollAddChoice    key.Binding
+	PollRemoveChoice key.Binding
+	PollMultiple     key.Binding
+	PollRemove       key.Binding
+	PollDone         key.Binding
 
 	// For detail
-	DetailReply  key.Binding
-	DetailReact  key.Binding
-	DetailRenote key.Binding
-	DetailQuit   key.Binding
+	DetailReply    key.Binding
+	DetailReact    key.Binding
+	DetailRenote   key.Binding
+	DetailQuote    key.Binding
+	DetailToggleCW key.Binding
+	DetailVote     key.Binding
+	DetailNextFile key.Binding
+	DetailOpenFile key.Binding
+	DetailProfile  key.Binding
+	DetailMentions key.Binding
+	DetailQuit     key.Binding
+
+	// For thread
+	ThreadQuit key.Binding
+
+	// For profile
+	ProfileFollow key.Binding
+	ProfileOpen   key.Binding
+	ProfileQuit   key.Binding
+
+	// For mention picker
+	MentionOpen key.Binding
+	MentionQuit key.Binding
+
+	// For search
+	SearchSubmit key.Binding
+	SearchFocus  key.Binding
+	SearchQuit   key.Binding
+
+	// For user search
+	UserSearchOpen key.Binding
+
+	// For notifications
+	NotificationOpen key.Binding
+	NotificationQuit key.Binding
+
+	// For antenna picker
+	AntennaSelect key.Binding
+	AntennaQuit   key.Binding
+
+	// For channel browser
+	ChannelOpen   key.Binding
+	ChannelFollow key.Binding
+	ChannelSource key.Binding
+	ChannelQuit   key.Binding
+
+	// For list picker
+	ListSelect key.Binding
+	ListQuit   key.Binding
+
+	// For columns
+	ColumnNext      key.Binding
+	ColumnPrev      key.Binding
+	ColumnMoveLeft  key.Binding
+	ColumnMoveRight key.Binding
+	ColumnRemove    key.Binding
+	ColumnQuit      key.Binding
+
+	// For account switcher
+	AccountSelect key.Binding
+	AccountQuit   key.Binding
+
+	// For recipient picker
+	RecipientToggle key.Binding
+	RecipientFocus  key.Binding
+	RecipientDone   key.Binding
+
+	// For reaction picker
+	PickerSelect key.Binding
+	PickerRemove key.Binding
+	PickerQuit   key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.PostSubmit, k.PostCancel}
+	return []key.Binding{k.PostSubmit, k.PostFocus, k.PostCancel}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.PostSubmit, k.PostCancel},
+		{k.PostSubmit, k.PostFocus, k.PostCancel},
+		{k.PostVisibility, k.PostLocalOnly, k.PostRecipients, k.PostPoll, k.PostAttach},
 	}
 }
 
@@ -65,6 +178,10 @@ func newKeyMap() keyMap {
 			key.WithKeys("t"),
 			key.WithHelp("t", "renote"),
 		),
+		Quote: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "quote"),
+		),
 		Detail: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "detail"),
@@ -73,10 +190,74 @@ func newKeyMap() keyMap {
 			key.WithKeys("h", "l", "s", "g"),
 			key.WithHelp("h/l/s/g", "switch"),
 		),
+		LoadNewer: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "load newer"),
+		),
+		Notify: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "notifications"),
+		),
+		Accounts: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "accounts"),
+		),
+		ToggleCW: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "show/hide cw"),
+		),
+		Profile: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "profile"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "search"),
+		),
+		FindUser: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "find user"),
+		),
+		Antennas: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "antennas"),
+		),
+		Channels: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "channels"),
+		),
+		Lists: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "lists"),
+		),
+		ListUser: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "add to list"),
+		),
+		Thread: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "thread"),
+		),
+		Columns: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "columns"),
+		),
+		AddColumn: key.NewBinding(
+			key.WithKeys("+"),
+			key.WithHelp("+", "add column"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c", "q"),
 			key.WithHelp("q/ctrl+c", "quit"),
 		),
+		LoadingCancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		LoadingQuit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
 		PostSubmit: key.NewBinding(
 			key.WithKeys("ctrl+s"),
 			key.WithHelp("ctrl+s", "post"),
@@ -85,6 +266,98 @@ func newKeyMap() keyMap {
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "cancel"),
 		),
+		PostFocus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "cw/text"),
+		),
+		PostVisibility: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "visibility"),
+		),
+		PostLocalOnly: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", "local only"),
+		),
+		PostRecipients: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "recipients"),
+		),
+		PostPoll: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "poll"),
+		),
+		PostAttach: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "attach files"),
+		),
+		AttachUpload: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "upload"),
+		),
+		AttachComplete: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "complete"),
+		),
+		AttachFocus: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "path/files"),
+		),
+		AttachDrive: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "from drive"),
+		),
+		AttachSensitive: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sensitive"),
+		),
+		AttachComment: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "alt text"),
+		),
+		AttachRemove: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "remove"),
+		),
+		AttachDone: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "done"),
+		),
+		DriveToggle: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "attach/detach"),
+		),
+		DriveQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		PollNext: key.NewBinding(
+			key.WithKeys("tab", "down", "enter"),
+			key.WithHelp("tab/↓", "next"),
+		),
+		PollPrev: key.NewBinding(
+			key.WithKeys("shift+tab", "up"),
+			key.WithHelp("shift+tab/↑", "previous"),
+		),
+		PollAddChoice: key.NewBinding(
+			key.WithKeys("ctrl+n"),
+			key.WithHelp("ctrl+n", "add choice"),
+		),
+		PollRemoveChoice: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "remove choice"),
+		),
+		PollMultiple: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "multiple"),
+		),
+		PollRemove: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "remove poll"),
+		),
+		PollDone: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "done"),
+		),
 		DetailReply: key.NewBinding(
 			key.WithKeys("R"),
 			key.WithHelp("R", "reply"),
@@ -97,10 +370,174 @@ func newKeyMap() keyMap {
 			key.WithKeys("t"),
 			key.WithHelp("t", "renote"),
 		),
+		DetailQuote: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "quote"),
+		),
+		DetailToggleCW: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "show/hide cw"),
+		),
+		DetailVote: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "vote"),
+		),
+		DetailNextFile: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "next file"),
+		),
+		DetailOpenFile: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open file"),
+		),
+		DetailProfile: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "author"),
+		),
+		DetailMentions: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mentions"),
+		),
 		DetailQuit: key.NewBinding(
 			key.WithKeys("ctrl+c", "q", "esc"),
 			key.WithHelp("q/esc", "quit"),
 		),
+		ThreadQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		ProfileFollow: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "follow/unfollow"),
+		),
+		ProfileOpen: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "detail"),
+		),
+		ProfileQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		MentionOpen: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "profile"),
+		),
+		MentionQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		SearchSubmit: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "search"),
+		),
+		SearchFocus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "query/results"),
+		),
+		SearchQuit: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+		UserSearchOpen: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "profile"),
+		),
+		NotificationOpen: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open"),
+		),
+		NotificationQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		AntennaSelect: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open"),
+		),
+		AntennaQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		ChannelOpen: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open"),
+		),
+		ChannelFollow: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "follow/unfollow"),
+		),
+		ChannelSource: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "followed/featured/owned"),
+		),
+		ChannelQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		ListSelect: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open/add/remove"),
+		),
+		ListQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		ColumnNext: key.NewBinding(
+			key.WithKeys("tab", "right"),
+			key.WithHelp("tab/→", "next column"),
+		),
+		ColumnPrev: key.NewBinding(
+			key.WithKeys("shift+tab", "left"),
+			key.WithHelp("shift+tab/←", "previous column"),
+		),
+		ColumnMoveLeft: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "move left"),
+		),
+		ColumnMoveRight: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "move right"),
+		),
+		ColumnRemove: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "remove column"),
+		),
+		ColumnQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		AccountSelect: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "switch"),
+		),
+		AccountQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "back"),
+		),
+		RecipientToggle: key.NewBinding(
+			key.WithKeys("enter", " "),
+			key.WithHelp("enter/space", "add/remove"),
+		),
+		RecipientFocus: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "search/list"),
+		),
+		RecipientDone: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "done"),
+		),
+		PickerSelect: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "react"),
+		),
+		PickerRemove: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "remove reaction"),
+		),
+		PickerQuit: key.NewBinding(
+			key.WithKeys("q", "esc"),
+			key.WithHelp("q/esc", "cancel"),
+		),
 	}
 }
 
@@ -108,96 +545,82 @@ func newKeyMap() keyMap {
 
 type model struct {
 	config        *Config
-	client        *http.Client
+	account       *Account
+	client        *misskey.Client
+	stream        *stream
 	keys          keyMap
 	help          help.Model
-	list          list.Model
-	detailList    list.Model
-	textarea      textarea.Model
-	viewport      viewport.Model
 	spinner       spinner.Model
-	timeline      string // "home", "local", "social", "global"
-	mode          string // "timeline", "posting", "detail"
-	detailFocus   string // "note", "replies"
-	replyToId     string // ID of the note being replied to
-	replyToNote   *Note  // The note being replied to
-	selectedNote  *Note
-	parentNote    *Note // The parent of the selected note
+	images        *imageCache
+	graphics      graphicsProtocol
+	cell          cellSize    // size of a character cell in pixels
+	screens       []screen    // navigation stack; the timeline is at the bottom and the top is shown
+	opening       screen      // the screen being loaded, pushed once its content arrives
+	emojis        []list.Item // the instance's custom emoji, for the reaction picker
+	emojisLoaded  bool
 	statusMessage string
+	userID        string
 	username      string
 	hostname      string
 	width         int
 	height        int
 	loading       bool
+	streaming     bool
 	err           error
+
+	// Requests for the current timeline and for the current detail or
+	// notifications view; cancelled when the user leaves them.
+	timelineCtx    context.Context
+	cancelTimeline context.CancelFunc
+	viewCtx        context.Context
+	cancelView     context.CancelFunc
 }
 
 // --- Initialization ---
 
-func newModel(config *Config, user *User) model {
-	keys := newKeyMap()
-
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = spinnerStyle
-
-	ta := textarea.New()
-	ta.Placeholder = "What's on your mind?"
-	ta.Focus()
-
+func newListDelegate() list.DefaultDelegate {
 	delegate := list.NewDefaultDelegate()
 	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(listDelegateSelectedTitleColor).BorderLeftForeground(listDelegateSelectedTitleColor)
 	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(listDelegateSelectedDescColor).BorderLeftForeground(listDelegateSelectedTitleColor)
+	return delegate
+}
 
-	mainList := list.New([]list.Item{}, delegate, 0, 0)
-	mainList.SetShowTitle(false)
-	mainList.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			keys.Post,
-			keys.Reply,
-			keys.React,
-			keys.Renote,
-			keys.Detail,
-			keys.Switch,
-		}
-	}
+func newModel(config *Config, account *Account, client *misskey.Client, user *misskey.User) model {
+	keys := newKeyMap()
 
-	detailList := list.New([]list.Item{}, delegate, 0, 0)
-	detailList.SetShowTitle(false)
-	detailList.AdditionalShortHelpKeys = func() []key.Binding {
-		return []key.Binding{
-			keys.DetailReply,
-			keys.DetailReact,
-			keys.DetailRenote,
-		}
-	}
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle
 
 	h := help.New()
 	h.ShowAll = true
 
-	instanceURL, err := url.Parse(config.InstanceURL)
-	if err != nil {
-		instanceURL.Host = ""
-	}
+	timelineCtx, cancelTimeline := context.WithCancel(context.Background())
+	viewCtx, cancelView := context.WithCancel(context.Background())
 
-	return model{
-		config:      config,
-		client:      &http.Client{Timeout: 10 * time.Second},
-		keys:        keys,
-		help:        h,
-		list:        mainList,
-		detailList:  detailList,
-		textarea:    ta,
-		spinner:     s,
-		timeline:    "home",
-		mode:        "timeline",
-		loading:     true,
-		username:    user.Username,
-		hostname:    instanceURL.Host,
-		detailFocus: "note",
+	m := model{
+		config:         config,
+		account:        account,
+		client:         client,
+		timelineCtx:    timelineCtx,
+		cancelTimeline: cancelTimeline,
+		viewCtx:        viewCtx,
+		cancelView:     cancelView,
+		keys:           keys,
+		help:           h,
+		spinner:        s,
+		images:         newImageCache(),
+		graphics:       detectGraphics(config.ImageProtocol),
+		cell:           terminalCellSize(),
+		loading:        true,
+		userID:         user.ID,
+		username:       user.Username,
+		hostname:       client.Host(),
 	}
+	m.screens = []screen{newTimelineScreen(&m, "home")}
+	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, m.fetchTimelineCmd())
+	return tea.Batch(m.spinner.Tick, m.fetchTimelineCmd(m.root()), m.waitForStreamCmd())
 }
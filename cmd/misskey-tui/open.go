@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openURL opens target with the platform's default handler.
+func openURL(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+	return start(cmd)
+}
+
+// openWith opens target with opener, a command line to which target is
+// appended, or with the default handler if opener is empty.
+func openWith(opener, target string) error {
+	args := strings.Fields(opener)
+	if len(args) == 0 {
+		return openURL(target)
+	}
+	return start(exec.Command(args[0], append(args[1:], target)...))
+}
+
+// start runs cmd in the background and reaps it once it exits.
+func start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
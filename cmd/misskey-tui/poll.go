@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// Limits Misskey enforces on new polls.
+const (
+	minPollChoices      = 2
+	maxPollChoices      = 10
+	maxPollChoiceLength = 50
+)
+
+const pollExpiryLayout = "2006-01-02 15:04"
+
+// pollEditor edits the poll attached to the note being composed.
+type pollEditor struct {
+	choices  []textinput.Model
+	expiry   textinput.Model
+	multiple bool
+	focus    int // index of the focused choice; len(choices) is the expiry field
+	width    int
+}
+
+func newPollEditor(width int) *pollEditor {
+	p := &pollEditor{width: width}
+	for range minPollChoices {
+		p.addChoice()
+	}
+
+	p.expiry = textinput.New()
+	p.expiry.Prompt = "Ends: "
+	p.expiry.Placeholder = "never (30m, 12h, 7d or " + pollExpiryLayout + ")"
+	p.setWidth(width)
+	return p
+}
+
+func (p *pollEditor) addChoice() tea.Cmd {
+	if len(p.choices) >= maxPollChoices {
+		return nil
+	}
+	ti := textinput.New()
+	ti.Placeholder = "Choice"
+	ti.CharLimit = maxPollChoiceLength
+	ti.Width = p.width
+	p.choices = append(p.choices, ti)
+	p.renumber()
+	return p.setFocus(len(p.choices) - 1)
+}
+
+func (p *pollEditor) removeChoice() tea.Cmd {
+	if len(p.choices) <= minPollChoices || p.focus >= len(p.choices) {
+		return nil
+	}
+	p.choices = slices.Delete(p.choices, p.focus, p.focus+1)
+	p.renumber()
+	return p.setFocus(min(p.focus, len(p.choices)-1))
+}
+
+func (p *pollEditor) renumber() {
+	for i := range p.choices {
+		p.choices[i].Prompt = fmt.Sprintf("%2d. ", i+1)
+	}
+}
+
+// field returns the input at index i: a choice, or the expiry field.
+func (p *pollEditor) field(i int) *textinput.Model {
+	if i < len(p.choices) {
+		return &p.choices[i]
+	}
+	return &p.expiry
+}
+
+func (p *pollEditor) setFocus(i int) tea.Cmd {
+	p.field(p.focus).Blur()
+	p.focus = (i + len(p.choices) + 1) % (len(p.choices) + 1)
+	return p.field(p.focus).Focus()
+}
+
+func (p *pollEditor) setWidth(width int) {
+	p.width = width
+	for i := range p.choices {
+		p.choices[i].Width = width - lipgloss.Width(p.choices[i].Prompt) - 1
+	}
+	p.expiry.Width = width - lipgloss.Width(p.expiry.Prompt) - 1
+}
+
+func (p *pollEditor) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	field := p.field(p.focus)
+	*field, cmd = field.Update(msg)
+	return cmd
+}
+
+// request validates the poll and converts it for /api/notes/create.
+func (p *pollEditor) request() (*misskey.PollRequest, error) {
+	req := &misskey.PollRequest{Multiple: p.multiple}
+	for _, choice := range p.choices {
+		if text := strings.TrimSpace(choice.Value()); text != "" {
+			req.Choices = append(req.Choices, text)
+		}
+	}
+	if len(req.Choices) < minPollChoices {
+		return nil, fmt.Errorf("a poll needs at least %d choices", minPollChoices)
+	}
+
+	var err error
+	req.ExpiresAt, req.ExpiredAfter, err = parsePollExpiry(p.expiry.Value(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// parsePollExpiry reads when a poll ends: empty for never, a duration such
+// as "30m", "12h" or "7d", or a local date and time. It returns either an
+// absolute expiresAt or a relative expiredAfter, in milliseconds.
+func parsePollExpiry(s string, now time.Time) (expiresAt, expiredAfter int64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	if t, err := time.ParseInLocation(pollExpiryLayout, s, time.Local); err == nil {
+		if !t.After(now) {
+			return 0, 0, errors.New("the poll must end in the future")
+		}
+		return t.UnixMilli(), 0, nil
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid poll duration %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return 0, 0, fmt.Errorf("invalid poll end %q", s)
+	}
+	if d <= 0 {
+		return 0, 0, errors.New("the poll must end in the future")
+	}
+	return 0, d.Milliseconds(), nil
+}
+
+// summary describes the poll in one line for the composer.
+func (p *pollEditor) summary() string {
+	filled := 0
+	for _, choice := range p.choices {
+		if strings.TrimSpace(choice.Value()) != "" {
+			filled++
+		}
+	}
+	parts := []string{fmt.Sprintf("Poll: %d choices", filled)}
+	if p.multiple {
+		parts = append(parts, "multiple")
+	}
+	if expiry := strings.TrimSpace(p.expiry.Value()); expiry != "" {
+		parts = append(parts, "ends "+expiry)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (p *pollEditor) view() string {
+	var b strings.Builder
+	for _, choice := range p.choices {
+		b.WriteString(choice.View())
+		b.WriteString("\n")
+	}
+	multiple := "[ ]"
+	if p.multiple {
+		multiple = "[x]"
+	}
+	b.WriteString(fmt.Sprintf("%s Allow multiple choices\n", multiple))
+	b.WriteString(p.expiry.View())
+	return b.String()
+}
+
+// pollExpired reports whether the poll has ended.
+func pollExpired(poll *misskey.Poll) bool {
+	if poll.ExpiresAt == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, poll.ExpiresAt)
+	return err == nil && !t.After(time.Now())
+}
+
+// renderPoll renders the poll results as bar charts for the detail view.
+func renderPoll(poll *misskey.Poll, width int) string {
+	total := 0
+	for _, choice := range poll.Choices {
+		total += choice.Votes
+	}
+	barWidth := max(min(width-16, 40), 10)
+
+	var b strings.Builder
+	for i, choice := range poll.Choices {
+		label := fmt.Sprintf("%d. %s", i+1, choice.Text)
+		if choice.IsVoted {
+			label = myReactionStyle.Render(label + " ✓")
+		}
+		b.WriteString(lipgloss.NewStyle().Width(width).Render(label))
+		b.WriteString("\n")
+
+		ratio := 0.0
+		if total > 0 {
+			ratio = float64(choice.Votes) / float64(total)
+		}
+		filled := int(ratio*float64(barWidth) + 0.5)
+		b.WriteString(pollBarStyle.Render(strings.Repeat("█", filled)))
+		b.WriteString(metadataStyle.Render(strings.Repeat("░", barWidth-filled)))
+		b.WriteString(fmt.Sprintf(" %d (%.0f%%)\n", choice.Votes, ratio*100))
+	}
+
+	footer := []string{fmt.Sprintf("%d votes", total)}
+	if poll.Multiple {
+		footer = append(footer, "multiple choice")
+	}
+	if poll.ExpiresAt != "" {
+		if t, err := time.Parse(time.RFC3339, poll.ExpiresAt); err == nil {
+			if pollExpired(poll) {
+				footer = append(footer, "ended "+t.Local().Format("2006-01-02 15:04"))
+			} else {
+				footer = append(footer, "ends "+t.Local().Format("2006-01-02 15:04"))
+			}
+		}
+	}
+	if !pollExpired(poll) {
+		footer = append(footer, "1-9 to vote")
+	}
+	b.WriteString(metadataStyle.Render(strings.Join(footer, " · ")))
+	return b.String()
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/mfm"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// profileBioLines caps the bio shown above the user's notes.
+const profileBioLines = 8
+
+// profileScreen shows a user's profile above their notes.
+type profileScreen struct {
+	user        *misskey.UserDetail
+	notes       list.Model
+	end         bool // no older notes left
+	loadingMore bool // fetching the next page of notes
+}
+
+func newProfileScreen(m *model) *profileScreen {
+	notes := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	notes.SetShowTitle(false)
+	notes.SetFilteringEnabled(false)
+	notes.DisableQuitKeybindings()
+	notes.SetStatusBarItemName("note", "notes")
+	notes.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keys.ProfileOpen,
+			m.keys.ProfileFollow,
+			m.keys.AddColumn,
+			m.keys.ProfileQuit,
+		}
+	}
+	s := &profileScreen{notes: notes}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openProfile starts loading the profile of the user req identifies, which
+// is shown on top of the current screen once it has loaded.
+func (m *model) openProfile(req misskey.ShowUserRequest) tea.Cmd {
+	m.resetViewContext()
+	m.loading = true
+	s := newProfileScreen(m)
+	m.opening = s
+	return tea.Batch(m.spinner.Tick, m.fetchProfileCmd(s, req))
+}
+
+// openUserProfile opens the profile of user, who is already known by ID.
+func (m *model) openUserProfile(user misskey.User) tea.Cmd {
+	return m.openProfile(misskey.ShowUserRequest{UserID: user.ID})
+}
+
+func (s *profileScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.notes.SetSize(width-h, height-v-3)
+}
+
+func (s *profileScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.ProfileQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.ProfileFollow):
+			return m.follow(s.user)
+		case key.Matches(msg, m.keys.AddColumn):
+			return m.addColumn(ColumnConfig{Source: userTimeline(s.user.ID), Title: "@" + acct(s.user.User)})
+		case key.Matches(msg, m.keys.ProfileOpen):
+			if selectedItem, ok := s.notes.SelectedItem().(item); ok {
+				return m.openDetail(&selectedItem.note)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.notes, cmd = s.notes.Update(msg)
+	return tea.Batch(cmd, s.loadOlderNotes(m))
+}
+
+func (s *profileScreen) View(m *model) string {
+	header := activeTabStyle.Render("PROFILE")
+	h, _ := docStyle.GetFrameSize()
+	box := unfocusedDetailContainerStyle.Width(max(m.width-h-2, 0)).Render(m.profileHeaderView(s.user, max(m.width-h-4, 0)))
+	status := m.statusBarView()
+	s.notes.SetHeight(max(m.height-lipgloss.Height(header)-lipgloss.Height(box)-lipgloss.Height(status), 0))
+	mainContent := docStyle.Render(lipgloss.JoinVertical(lipgloss.Left, box, s.notes.View()))
+	return header + "\n" + mainContent + "\n" + status
+}
+
+// loadOlderNotes loads the next page of the user's notes when the cursor
+// reaches the bottom of the list.
+func (s *profileScreen) loadOlderNotes(m *model) tea.Cmd {
+	items := s.notes.Items()
+	if s.loadingMore || s.end || len(items) == 0 || s.notes.Index() < len(items)-1 {
+		return nil
+	}
+	last, ok := items[len(items)-1].(item)
+	if !ok {
+		return nil
+	}
+	s.loadingMore = true
+	m.statusMessage = "Loading older notes..."
+	return m.fetchProfileNotesCmd(s, last.note.ID)
+}
+
+// follow follows or unfollows user, or withdraws a pending follow request.
+func (m *model) follow(user *misskey.UserDetail) tea.Cmd {
+	if user.ID == m.userID {
+		return nil
+	}
+	action := "follow"
+	switch {
+	case user.IsFollowing:
+		action = "unfollow"
+	case user.HasPendingFollowRequestFromYou:
+		action = "cancel"
+	}
+	return m.followCmd(user.ID, action)
+}
+
+// relationship describes how the authenticated user and user are related.
+func (m *model) relationship(user *misskey.UserDetail) []string {
+	if user.ID == m.userID {
+		return []string{"This is you"}
+	}
+	var labels []string
+	switch {
+	case user.IsFollowing:
+		labels = append(labels, "Following")
+	case user.HasPendingFollowRequestFromYou:
+		labels = append(labels, "Follow requested")
+	}
+	switch {
+	case user.IsFollowed:
+		labels = append(labels, "Follows you")
+	case user.HasPendingFollowRequestToYou:
+		labels = append(labels, "Wants to follow you")
+	}
+	if user.IsBlocking {
+		labels = append(labels, "Blocking")
+	}
+	if user.IsBlocked {
+		labels = append(labels, "Blocks you")
+	}
+	if user.IsMuted {
+		labels = append(labels, "Muted")
+	}
+	return labels
+}
+
+// profileHeaderView renders the user's name, bio, fields, counts and
+// relationship for the top of their profile.
+func (m *model) profileHeaderView(user *misskey.UserDetail, width int) string {
+	var b strings.Builder
+
+	name := user.Name
+	if name == "" {
+		name = user.Username
+	}
+	b.WriteString(renderMFM(name, width))
+	b.WriteString("\n")
+	handle := "@" + acct(user.User)
+	if user.Host == "" {
+		handle += "@" + m.hostname
+	}
+	if user.IsLocked {
+		handle += " 🔒"
+	}
+	if user.IsBot {
+		handle += " [bot]"
+	}
+	b.WriteString(metadataStyle.Render(handle))
+
+	if user.Description != "" {
+		b.WriteString("\n\n")
+		bio := renderMFM(user.Description, width)
+		if lines := strings.Split(bio, "\n"); len(lines) > profileBioLines {
+			bio = strings.Join(lines[:profileBioLines], "\n") + "\n" + metadataStyle.Render("…")
+		}
+		b.WriteString(bio)
+	}
+
+	if len(user.Fields) > 0 {
+		b.WriteString("\n")
+		labelWidth := 0
+		for _, field := range user.Fields {
+			labelWidth = max(labelWidth, lipgloss.Width(field.Name))
+		}
+		for _, field := range user.Fields {
+			label := metadataStyle.Render(field.Name + strings.Repeat(" ", labelWidth-lipgloss.Width(field.Name)))
+			b.WriteString("\n" + label + "  " + plainMFM(field.Value))
+		}
+	}
+
+	b.WriteString("\n\n")
+	counts := []string{
+		strconv.Itoa(user.NotesCount) + " notes",
+		strconv.Itoa(user.FollowingCount) + " following",
+		strconv.Itoa(user.FollowersCount) + " followers",
+	}
+	b.WriteString(strings.Join(counts, " · "))
+	if labels := m.relationship(user); len(labels) > 0 {
+		b.WriteString("  " + visibilityStyle.Render(strings.Join(labels, " · ")))
+	}
+	return b.String()
+}
+
+// noteMentions returns the users mentioned in the note, in order of first
+// mention. Mentions without a host are relative to the author's instance.
+func noteMentions(note *misskey.Note) []misskey.User {
+	var users []misskey.User
+	seen := map[string]bool{}
+	var walk func(nodes []mfm.Node)
+	walk = func(nodes []mfm.Node) {
+		for _, n := range nodes {
+			if n.Kind == mfm.Mention {
+				user := misskey.User{Username: n.Value, Host: n.Host}
+				if user.Host == "" {
+					user.Host = note.User.Host
+				}
+				if !seen[acct(user)] {
+					seen[acct(user)] = true
+					users = append(users, user)
+				}
+			}
+			walk(n.Children)
+		}
+	}
+	walk(mfm.Parse(note.Text))
+	return users
+}
+
+// mentionProfile opens the profile of a mentioned user, who is only known
+// by username and host.
+func (m *model) mentionProfile(user misskey.User) tea.Cmd {
+	host := user.Host
+	if strings.EqualFold(host, m.hostname) {
+		host = ""
+	}
+	return m.openProfile(misskey.ShowUserRequest{Username: user.Username, Host: host})
+}
+
+// followStatus is the status bar message after a follow action succeeded.
+func followStatus(user *misskey.UserDetail, action string) string {
+	switch action {
+	case "unfollow":
+		return fmt.Sprintf("Unfollowed @%s", acct(user.User))
+	case "cancel":
+		return fmt.Sprintf("Cancelled follow request to @%s", acct(user.User))
+	}
+	if user.IsLocked {
+		return fmt.Sprintf("Sent follow request to @%s", acct(user.User))
+	}
+	return fmt.Sprintf("Followed @%s", acct(user.User))
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// screen is a view on the navigation stack. Only the screen on top gets
+// messages and is drawn; the screens below it keep their state, such as
+// list cursors and scroll positions, until they are back on top.
+type screen interface {
+	Update(m *model, msg tea.Msg) tea.Cmd
+	View(m *model) string
+}
+
+// top returns the screen being shown.
+func (m *model) top() screen {
+	return m.screens[len(m.screens)-1]
+}
+
+// push shows s on top of the current screen.
+func (m *model) push(s screen) {
+	m.screens = append(m.screens, s)
+}
+
+// pop goes back to the screen below the top one. The timeline at the bottom
+// of the stack is never popped.
+func (m *model) pop() {
+	if len(m.screens) > 1 {
+		m.screens = m.screens[:len(m.screens)-1]
+	}
+}
+
+// onStack reports whether s is on the navigation stack. Results for screens
+// that have been closed are dropped.
+func (m *model) onStack(s screen) bool {
+	return slices.Contains(m.screens, s)
+}
+
+// resizer is implemented by screens whose layout depends on the window
+// size.
+type resizer interface {
+	setSize(width, height int)
+}
+
+// newPickerList returns a list for a picker screen with keys as its extra
+// help.
+func newPickerList(keys ...key.Binding) list.Model {
+	l := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	l.SetShowTitle(false)
+	l.AdditionalShortHelpKeys = func() []key.Binding { return keys }
+	return l
+}
+
+// notificationsScreen lists the latest notifications.
+type notificationsScreen struct {
+	list list.Model
+}
+
+func newNotificationsScreen(m *model) *notificationsScreen {
+	s := &notificationsScreen{list: newPickerList(m.keys.NotificationOpen, m.keys.AddColumn, m.keys.NotificationQuit)}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *notificationsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *notificationsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.NotificationQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.AddColumn):
+			return m.addColumn(ColumnConfig{Source: notificationsTimeline, Title: "NOTIFICATIONS"})
+		case key.Matches(msg, m.keys.NotificationOpen):
+			if selectedItem, ok := s.list.SelectedItem().(notificationItem); ok {
+				if note := selectedItem.notification.Note; note != nil {
+					return m.openDetail(note)
+				} else if user := selectedItem.notification.User; user != nil {
+					return m.openUserProfile(*user)
+				}
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *notificationsScreen) View(m *model) string {
+	header := activeTabStyle.Render("NOTIFICATIONS")
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// antennasScreen picks an antenna whose timeline to show.
+type antennasScreen struct {
+	list list.Model
+}
+
+func newAntennasScreen(m *model) *antennasScreen {
+	s := &antennasScreen{list: newPickerList(m.keys.AntennaSelect, m.keys.AntennaQuit)}
+	s.list.SetStatusBarItemName("antenna", "antennas")
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *antennasScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *antennasScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.AntennaQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.AntennaSelect):
+			if selectedItem, ok := s.list.SelectedItem().(antennaItem); ok {
+				antenna := selectedItem.antenna
+				root := m.root()
+				root.antenna = &antenna
+				m.pop()
+				return root.switchTimeline(m, antennaTimeline(antenna.ID))
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *antennasScreen) View(m *model) string {
+	header := activeTabStyle.Render("ANTENNAS")
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// channelsScreen browses the followed, featured or owned channels.
+type channelsScreen struct {
+	list   list.Model
+	source string // which channels are listed
+}
+
+func newChannelsScreen(m *model) *channelsScreen {
+	s := &channelsScreen{
+		list:   newPickerList(m.keys.ChannelOpen, m.keys.ChannelFollow, m.keys.ChannelSource, m.keys.ChannelQuit),
+		source: channelSources[0],
+	}
+	s.list.SetStatusBarItemName("channel", "channels")
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *channelsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *channelsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.ChannelQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.ChannelSource):
+			i := slices.Index(channelSources, s.source)
+			s.source = channelSources[(i+1)%len(channelSources)]
+			m.resetViewContext()
+			m.loading = true
+			return tea.Batch(m.spinner.Tick, m.fetchChannelsCmd(s, s.source))
+		case key.Matches(msg, m.keys.ChannelFollow):
+			if selectedItem, ok := s.list.SelectedItem().(channelItem); ok {
+				return m.followChannelCmd(selectedItem.channel)
+			}
+			return nil
+		case key.Matches(msg, m.keys.ChannelOpen):
+			if selectedItem, ok := s.list.SelectedItem().(channelItem); ok {
+				channel := selectedItem.channel
+				root := m.root()
+				root.channel = &channel
+				m.pop()
+				return root.switchTimeline(m, channelTimeline(channel.ID))
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *channelsScreen) View(m *model) string {
+	var tabs []string
+	for _, source := range channelSources {
+		style := inactiveTabStyle
+		if source == s.source {
+			style = activeTabStyle
+		}
+		tabs = append(tabs, style.Render(strings.ToUpper(source)))
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// listsScreen is the list picker. With member set, choosing a list adds the
+// user to it or removes them from it; otherwise it opens the list's
+// timeline.
+type listsScreen struct {
+	list   list.Model
+	member *misskey.User
+}
+
+func newListsScreen(m *model, member *misskey.User) *listsScreen {
+	s := &listsScreen{list: newPickerList(m.keys.ListSelect, m.keys.ListQuit), member: member}
+	s.list.SetStatusBarItemName("list", "lists")
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *listsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *listsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.ListQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.ListSelect):
+			selectedItem, ok := s.list.SelectedItem().(userListItem)
+			if !ok {
+				return nil
+			}
+			if s.member != nil {
+				added := slices.Contains(selectedItem.list.UserIDs, s.member.ID)
+				return m.listMemberCmd(selectedItem.list, *s.member, !added)
+			}
+			userList := selectedItem.list
+			root := m.root()
+			root.userList = &userList
+			m.pop()
+			return root.switchTimeline(m, listTimeline(userList.ID))
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *listsScreen) View(m *model) string {
+	title := "LISTS"
+	if s.member != nil {
+		title = "LISTS: @" + acct(*s.member)
+	}
+	header := activeTabStyle.Render(title)
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// accountsScreen switches between the configured accounts.
+type accountsScreen struct {
+	list list.Model
+}
+
+func newAccountsScreen(m *model) *accountsScreen {
+	s := &accountsScreen{list: newPickerList(m.keys.AccountSelect, m.keys.AccountQuit)}
+	s.list.SetItems(m.accountItems())
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *accountsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *accountsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.AccountQuit):
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.AccountSelect):
+			if selectedItem, ok := s.list.SelectedItem().(accountItem); ok {
+				if selectedItem.current {
+					m.pop()
+					return nil
+				}
+				m.resetViewContext()
+				m.loading = true
+				return tea.Batch(m.spinner.Tick, m.switchAccountCmd(selectedItem.account))
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *accountsScreen) View(m *model) string {
+	header := activeTabStyle.Render("ACCOUNTS")
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// mentionsScreen picks one of the users mentioned in a note to open.
+type mentionsScreen struct {
+	list list.Model
+}
+
+func newMentionsScreen(m *model, users []misskey.User) *mentionsScreen {
+	s := &mentionsScreen{list: newPickerList(m.keys.MentionOpen, m.keys.MentionQuit)}
+	s.list.SetFilteringEnabled(false)
+	s.list.DisableQuitKeybindings()
+	s.list.SetStatusBarItemName("user", "users")
+	items := make([]list.Item, len(users))
+	for i, user := range users {
+		items[i] = userItem{user: user}
+	}
+	s.list.SetItems(items)
+	s.setSize(m.width, m.height)
+	return s
+}
+
+func (s *mentionsScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-3)
+}
+
+func (s *mentionsScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.MentionQuit):
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.MentionOpen):
+			if selectedItem, ok := s.list.SelectedItem().(userItem); ok {
+				m.pop()
+				return m.mentionProfile(selectedItem.user)
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *mentionsScreen) View(m *model) string {
+	header := activeTabStyle.Render("MENTIONS")
+	mainContent := docStyle.Render(s.list.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
+
+// reactionScreen is the reaction picker for a note.
+type reactionScreen struct {
+	note *misskey.Note
+	list list.Model
+}
+
+func newReactionScreen(m *model, note *misskey.Note) *reactionScreen {
+	s := &reactionScreen{note: note, list: newPickerList(m.keys.PickerSelect, m.keys.PickerRemove, m.keys.PickerQuit)}
+	s.list.SetStatusBarItemName("emoji", "emoji")
+	s.list.SetItems(m.emojis)
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openReactionPicker shows the reaction picker for note, loading the
+// instance's custom emoji the first time.
+func (m *model) openReactionPicker(note *misskey.Note) tea.Cmd {
+	if note.Renote != nil && note.Text == "" {
+		note = note.Renote
+	}
+	m.push(newReactionScreen(m, note))
+	if !m.emojisLoaded {
+		return m.fetchEmojisCmd()
+	}
+	return nil
+}
+
+func (s *reactionScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(width-h, height-v-9)
+}
+
+// react closes the picker and reacts to its note with reaction.
+func (s *reactionScreen) react(m *model, reaction string) tea.Cmd {
+	m.pop()
+	return m.createReactionCmd(s.note.ID, reaction, s.note.MyReaction != "")
+}
+
+func (s *reactionScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		switch {
+		case key.Matches(msg, m.keys.PickerQuit) && s.list.FilterState() == list.Unfiltered:
+			m.pop()
+			return nil
+		case key.Matches(msg, m.keys.PickerRemove):
+			if s.note.MyReaction == "" {
+				return nil
+			}
+			m.pop()
+			return m.deleteReactionCmd(s.note.ID)
+		case key.Matches(msg, m.keys.PickerSelect):
+			if selectedItem, ok := s.list.SelectedItem().(emojiItem); ok {
+				return s.react(m, ":"+selectedItem.emoji.Name+":")
+			}
+			return nil
+		default:
+			favorites := m.config.favoriteReactions()
+			if n, err := strconv.Atoi(msg.String()); err == nil && n >= 1 && n <= min(len(favorites), 9) {
+				return s.react(m, favorites[n-1])
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return cmd
+}
+
+func (s *reactionScreen) View(m *model) string {
+	note := s.note
+	header := activeTabStyle.Render("REACT")
+
+	textWidth := max(m.width-7, 0)
+	quote := quoteBoxStyle.Render(fmt.Sprintf("@%s\n%s",
+		note.User.Username,
+		lipgloss.NewStyle().MaxWidth(textWidth).Render(plainNoteText(note, false)),
+	))
+
+	var favorites []string
+	for i, r := range m.config.favoriteReactions() {
+		if i >= 9 {
+			break
+		}
+		favorites = append(favorites, fmt.Sprintf("%s %s", metadataStyle.Render(strconv.Itoa(i+1)), r))
+	}
+	favoritesRow := "Favourites: " + strings.Join(favorites, "  ")
+
+	current := metadataStyle.Render("Your reaction: none")
+	if note.MyReaction != "" {
+		current = fmt.Sprintf("Your reaction: %s %s",
+			myReactionStyle.Render(reactionLabel(note.MyReaction)),
+			metadataStyle.Render("(x to remove)"),
+		)
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		quote,
+		"",
+		favoritesRow,
+		current,
+		"",
+		s.list.View(),
+	)
+	return header + "\n" + docStyle.Render(content) + "\n" + m.statusBarView()
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchScreen searches notes. The last one is kept on the timeline screen,
+// so that its query and results are still there when search is opened again.
+type searchScreen struct {
+	input       textinput.Model
+	results     list.Model
+	query       string // query the results are for
+	end         bool   // no more results
+	loadingMore bool   // fetching the next page of results
+}
+
+func newSearchScreen(m *model) *searchScreen {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = "keywords or #hashtag"
+
+	results := newPickerList(
+		m.keys.Detail,
+		m.keys.Reply,
+		m.keys.React,
+		m.keys.Renote,
+		m.keys.Quote,
+		m.keys.Profile,
+		m.keys.AddColumn,
+		m.keys.SearchFocus,
+		m.keys.SearchQuit,
+	)
+	results.SetFilteringEnabled(false)
+	results.DisableQuitKeybindings()
+	results.SetStatusBarItemName("note", "notes")
+
+	s := &searchScreen{input: input, results: results}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openSearch shows the note search, with the last query and results if it
+// has been opened before.
+func (m *model) openSearch() tea.Cmd {
+	root := m.root()
+	s := root.lastSearch
+	if s == nil || m.onStack(s) {
+		s = newSearchScreen(m)
+		root.lastSearch = s
+	}
+	s.setSize(m.width, m.height)
+	m.push(s)
+	if len(s.results.Items()) > 0 {
+		s.input.Blur()
+		return nil
+	}
+	return s.input.Focus()
+}
+
+func (s *searchScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.input.Width = width - h - lipgloss.Width(s.input.Prompt) - 1
+	s.results.SetSize(width-h, height-v-5)
+}
+
+func (s *searchScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if s.input.Focused() {
+			switch {
+			case key.Matches(msg, m.keys.SearchQuit):
+				m.resetViewContext()
+				m.pop()
+				return nil
+			case key.Matches(msg, m.keys.SearchFocus):
+				if len(s.results.Items()) > 0 {
+					s.input.Blur()
+				}
+				return nil
+			case key.Matches(msg, m.keys.SearchSubmit):
+				query := strings.TrimSpace(s.input.Value())
+				if query == "" {
+					return nil
+				}
+				m.resetViewContext()
+				s.query = query
+				s.end = false
+				m.loading = true
+				return tea.Batch(m.spinner.Tick, m.searchNotesCmd(s, query, ""))
+			}
+		} else {
+			selectedItem, ok := s.results.SelectedItem().(item)
+			switch {
+			case key.Matches(msg, m.keys.SearchQuit):
+				m.resetViewContext()
+				m.pop()
+				return nil
+			case key.Matches(msg, m.keys.SearchFocus):
+				return s.input.Focus()
+			case key.Matches(msg, m.keys.AddColumn):
+				return m.addColumn(ColumnConfig{Source: searchTimeline(s.query), Title: "SEARCH: " + s.query})
+			case !ok:
+			case key.Matches(msg, m.keys.Detail):
+				return m.openDetail(&selectedItem.note)
+			case key.Matches(msg, m.keys.Reply):
+				return m.openComposer(&selectedItem.note)
+			case key.Matches(msg, m.keys.React):
+				return m.openReactionPicker(&selectedItem.note)
+			case key.Matches(msg, m.keys.Renote):
+				return m.createRenoteCmd(selectedItem.note.ID)
+			case key.Matches(msg, m.keys.Quote):
+				return m.openQuoteComposer(&selectedItem.note)
+			case key.Matches(msg, m.keys.ToggleCW):
+				if noteCW(&selectedItem.note) != "" {
+					selectedItem.expanded = !selectedItem.expanded
+					return s.results.SetItem(s.results.Index(), selectedItem)
+				}
+				return nil
+			case key.Matches(msg, m.keys.Profile):
+				note := &selectedItem.note
+				if note.Renote != nil && note.Text == "" {
+					note = note.Renote
+				}
+				return m.openUserProfile(note.User)
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.input.Focused() {
+		s.input, cmd = s.input.Update(msg)
+		return cmd
+	}
+	s.results, cmd = s.results.Update(msg)
+	return tea.Batch(cmd, s.loadMore(m))
+}
+
+func (s *searchScreen) View(m *model) string {
+	header := activeTabStyle.Render("SEARCH")
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		s.input.View(),
+		"",
+		s.results.View(),
+	)
+	return header + "\n" + docStyle.Render(content) + "\n" + m.statusBarView()
+}
+
+// loadMore loads the next page of results when the cursor reaches the
+// bottom of the list.
+func (s *searchScreen) loadMore(m *model) tea.Cmd {
+	items := s.results.Items()
+	if s.loadingMore || s.end || len(items) == 0 || s.results.Index() < len(items)-1 {
+		return nil
+	}
+	last, ok := items[len(items)-1].(item)
+	if !ok {
+		return nil
+	}
+	s.loadingMore = true
+	m.statusMessage = "Loading more results..."
+	return m.searchNotesCmd(s, s.query, last.note.ID)
+}
+
+// userSearchScreen finds users by name, acct or profile URL. Like
+// searchScreen, the last one is kept on the timeline screen.
+type userSearchScreen struct {
+	input       textinput.Model
+	results     list.Model
+	query       string // query the results are for
+	end         bool   // no more results
+	loadingMore bool   // fetching the next page of results
+}
+
+func newUserSearchScreen(m *model) *userSearchScreen {
+	input := textinput.New()
+	input.Prompt = "Find: "
+	input.Placeholder = "name, @user@host or profile URL"
+
+	results := newPickerList(m.keys.UserSearchOpen, m.keys.SearchFocus, m.keys.SearchQuit)
+	results.SetFilteringEnabled(false)
+	results.DisableQuitKeybindings()
+	results.SetStatusBarItemName("user", "users")
+
+	s := &userSearchScreen{input: input, results: results}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openUserSearch shows the user search, with the last query and results if
+// it has been opened before.
+func (m *model) openUserSearch() tea.Cmd {
+	root := m.root()
+	s := root.lastUserSearch
+	if s == nil || m.onStack(s) {
+		s = newUserSearchScreen(m)
+		root.lastUserSearch = s
+	}
+	s.setSize(m.width, m.height)
+	m.push(s)
+	if len(s.results.Items()) > 0 {
+		s.input.Blur()
+		return nil
+	}
+	return s.input.Focus()
+}
+
+func (s *userSearchScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.input.Width = width - h - lipgloss.Width(s.input.Prompt) - 1
+	s.results.SetSize(width-h, height-v-5)
+}
+
+func (s *userSearchScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if s.input.Focused() {
+			switch {
+			case key.Matches(msg, m.keys.SearchQuit):
+				m.resetViewContext()
+				m.pop()
+				return nil
+			case key.Matches(msg, m.keys.SearchFocus):
+				if len(s.results.Items()) > 0 {
+					s.input.Blur()
+				}
+				return nil
+			case key.Matches(msg, m.keys.SearchSubmit):
+				query := strings.TrimSpace(s.input.Value())
+				if query == "" {
+					return nil
+				}
+				m.resetViewContext()
+				s.query = query
+				s.end = false
+				m.loading = true
+				return tea.Batch(m.spinner.Tick, m.searchUsersCmd(s, query, 0))
+			}
+		} else {
+			switch {
+			case key.Matches(msg, m.keys.SearchQuit):
+				m.resetViewContext()
+				m.pop()
+				return nil
+			case key.Matches(msg, m.keys.SearchFocus):
+				return s.input.Focus()
+			case key.Matches(msg, m.keys.UserSearchOpen):
+				if selectedItem, ok := s.results.SelectedItem().(userItem); ok {
+					return m.openUserProfile(selectedItem.user)
+				}
+				return nil
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.input.Focused() {
+		s.input, cmd = s.input.Update(msg)
+		return cmd
+	}
+	s.results, cmd = s.results.Update(msg)
+	return tea.Batch(cmd, s.loadMore(m))
+}
+
+func (s *userSearchScreen) View(m *model) string {
+	header := activeTabStyle.Render("FIND USER")
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		s.input.View(),
+		"",
+		s.results.View(),
+	)
+	return header + "\n" + docStyle.Render(content) + "\n" + m.statusBarView()
+}
+
+// loadMore loads the next page of results when the cursor reaches the
+// bottom of the list.
+func (s *userSearchScreen) loadMore(m *model) tea.Cmd {
+	n := len(s.results.Items())
+	if s.loadingMore || s.end || n == 0 || s.results.Index() < n-1 {
+		return nil
+	}
+	s.loadingMore = true
+	m.statusMessage = "Loading more results..."
+	return m.searchUsersCmd(s, s.query, n)
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/coder/websocket"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// --- Streaming API ---
+
+const (
+	streamDialTimeout  = 10 * time.Second
+	streamWriteTimeout = 10 * time.Second
+	streamPingInterval = 30 * time.Second
+	streamPongTimeout  = 30 * time.Second
+	streamMaxBackoff   = 30 * time.Second
+	streamMaxMessage   = 16 << 20
+)
+
+type streamMessage struct {
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
+}
+
+type streamChannelEvent struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
+}
+
+// stream keeps a connection to the Misskey streaming API open, reconnecting
+// when it drops, and delivers events to the program through events. One
+// connection carries a channel for every timeline being streamed.
+type stream struct {
+	client *misskey.Client
+	events chan tea.Msg
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	timelines []string
+	channels  map[string]string // timeline of each channel ID on conn
+	closed    bool
+	done      chan struct{}
+}
+
+func newStream(client *misskey.Client, timelines ...string) *stream {
+	return &stream{
+		client:    client,
+		events:    make(chan tea.Msg, 64),
+		timelines: uniqueTimelines(timelines),
+		channels:  map[string]string{},
+		done:      make(chan struct{}),
+	}
+}
+
+// run connects and reads until close is called.
+func (s *stream) run() {
+	backoff := time.Second
+	for {
+		connected, err := s.connectAndRead()
+		if s.isClosed() {
+			return
+		}
+		if connected {
+			backoff = time.Second
+		}
+		s.emit(streamStatusMsg{stream: s, connected: false, err: err})
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return
+		}
+		backoff = min(backoff*2, streamMaxBackoff)
+	}
+}
+
+func (s *stream) connectAndRead() (connected bool, err error) {
+	endpoint, err := s.client.StreamingURL()
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), streamDialTimeout)
+	conn, _, err := websocket.Dial(ctx, endpoint, nil)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	conn.SetReadLimit(streamMaxMessage)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.CloseNow()
+		return false, nil
+	}
+	s.conn = conn
+	s.channels = map[string]string{}
+	for _, timeline := range s.timelines {
+		if err = s.connectChannelLocked(timeline); err != nil {
+			break
+		}
+	}
+	s.mu.Unlock()
+	if err != nil {
+		conn.CloseNow()
+		return false, err
+	}
+
+	s.emit(streamStatusMsg{stream: s, connected: true})
+
+	stopPing := make(chan struct{})
+	defer close(stopPing)
+	go s.keepAlive(conn, stopPing)
+
+	defer func() {
+		s.mu.Lock()
+		if s.conn == conn {
+			s.conn = nil
+		}
+		s.mu.Unlock()
+		conn.CloseNow()
+	}()
+
+	for {
+		_, data, err := conn.Read(context.Background())
+		if err != nil {
+			return true, err
+		}
+		s.handleMessage(data)
+	}
+}
+
+// keepAlive pings the server, dropping the connection when it stops
+// answering so that the read loop reconnects.
+func (s *stream) keepAlive(conn *websocket.Conn, stop <-chan struct{}) {
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), streamPongTimeout)
+			err := conn.Ping(ctx)
+			cancel()
+			if err != nil {
+				conn.CloseNow()
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *stream) handleMessage(data []byte) {
+	var msg streamMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "channel" {
+		return
+	}
+
+	var event streamChannelEvent
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	timeline, current := s.channels[event.ID]
+	s.mu.Unlock()
+	if !current {
+		return
+	}
+
+	switch event.Type {
+	case "note":
+		var note misskey.Note
+		if err := json.Unmarshal(event.Body, &note); err != nil {
+			return
+		}
+		s.emit(streamNoteMsg{stream: s, timeline: timeline, note: note})
+	case "notification":
+		var notification misskey.Notification
+		if err := json.Unmarshal(event.Body, &notification); err != nil || !slices.Contains(notificationTypes, notification.Type) {
+			return
+		}
+		s.emit(streamNotificationMsg{stream: s, notification: notification})
+	}
+}
+
+// subscribe switches the stream to the channels matching timelines,
+// keeping the channels of timelines it already streams.
+func (s *stream) subscribe(timelines ...string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timelines = uniqueTimelines(timelines)
+	if slices.Equal(s.timelines, timelines) {
+		return
+	}
+	s.timelines = timelines
+	if s.conn == nil {
+		return
+	}
+
+	connected := map[string]bool{}
+	for id, timeline := range s.channels {
+		if slices.Contains(timelines, timeline) {
+			connected[timeline] = true
+			continue
+		}
+		s.sendLocked("disconnect", map[string]any{"id": id})
+		delete(s.channels, id)
+	}
+	for _, timeline := range timelines {
+		if connected[timeline] {
+			continue
+		}
+		if err := s.connectChannelLocked(timeline); err != nil {
+			// Force the read loop to notice and reconnect.
+			s.conn.CloseNow()
+			return
+		}
+	}
+}
+
+// streams reports whether the stream is connected to timeline's channel, so
+// that its new notes arrive without being fetched.
+func (s *stream) streams(timeline string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return false
+	}
+	for _, t := range s.channels {
+		if t == timeline {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stream) connectChannelLocked(timeline string) error {
+	channel, params, ok := streamChannel(timeline)
+	if !ok {
+		return nil
+	}
+	id, err := newStreamChannelID()
+	if err != nil {
+		return err
+	}
+	if err := s.sendLocked("connect", map[string]any{
+		"channel": channel,
+		"id":      id,
+		"params":  params,
+	}); err != nil {
+		return err
+	}
+	s.channels[id] = timeline
+	return nil
+}
+
+func (s *stream) sendLocked(msgType string, body any) error {
+	data, err := json.Marshal(map[string]any{"type": msgType, "body": body})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), streamWriteTimeout)
+	defer cancel()
+	return s.conn.Write(ctx, websocket.MessageText, data)
+}
+
+func (s *stream) emit(msg tea.Msg) {
+	select {
+	case s.events <- msg:
+	case <-s.done:
+	}
+}
+
+func (s *stream) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *stream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.done)
+	if s.conn != nil {
+		s.conn.CloseNow()
+	}
+}
+
+// uniqueTimelines returns timelines without duplicates, keeping the first
+// of each.
+func uniqueTimelines(timelines []string) []string {
+	var unique []string
+	for _, timeline := range timelines {
+		if !slices.Contains(unique, timeline) {
+			unique = append(unique, timeline)
+		}
+	}
+	return unique
+}
+
+func newStreamChannelID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
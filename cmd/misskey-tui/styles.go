@@ -25,6 +25,23 @@ var (
 
 	metadataStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
+	myReactionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#86b300")).Bold(true)
+
+	cwStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff9156")).Bold(true)
+	visibilityStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#44a4c1"))
+	pollBarStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#86b300"))
+
+	mfmMentionStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#86b300"))
+	mfmHashtagStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff9156"))
+	mfmLinkStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("#44a4c1")).Underline(true)
+	mfmEmojiStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#c7a000"))
+	mfmInlineCodeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e06c75")).Background(lipgloss.Color("236"))
+	mfmCodeBlockStyle  = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("252")).
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(lipgloss.Color("#44a4c1")).
+				PaddingLeft(1)
+
 	repliesHeaderStyle = lipgloss.NewStyle().
 				Bold(true).
 				Padding(0, 1).
@@ -33,6 +50,9 @@ var (
 
 	spinnerStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#86b300"))
 
+	liveIndicatorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#86b300"))
+	offlineIndicatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
 	listDelegateSelectedTitleColor = lipgloss.Color("#86b300")
 	listDelegateSelectedDescColor  = lipgloss.Color("#688a00ff")
 
@@ -40,4 +60,10 @@ var (
 					BorderForeground(lipgloss.Color("#86b300"))
 	unfocusedDetailContainerStyle = detailContainerStyle.Copy().
 					BorderForeground(lipgloss.Color("240"))
+
+	focusedColumnStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("#86b300"))
+	unfocusedColumnStyle = focusedColumnStyle.
+				BorderForeground(lipgloss.Color("240"))
 )
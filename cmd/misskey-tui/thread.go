@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// maxThreadRequests caps how many notes' replies are fetched for a thread,
+// so that huge threads don't flood the server. threadPageSize is the most
+// ancestors or replies fetched for a note.
+const (
+	maxThreadRequests = 50
+	threadPageSize    = 100
+)
+
+// threadNode is a note in a thread with the replies to it, oldest first.
+type threadNode struct {
+	note    misskey.Note
+	replies []*threadNode
+}
+
+// threadItem is a note in the thread list. lead and cont are the tree
+// guides in front of its title and description.
+type threadItem struct {
+	item
+	lead, cont string
+}
+
+func (i threadItem) Title() string       { return i.lead + i.item.Title() }
+func (i threadItem) Description() string { return i.cont + i.item.Description() }
+
+// threadScreen shows the whole conversation a note is part of as a tree,
+// from the root of the thread down to the last reply.
+type threadScreen struct {
+	noteID    string // the note the thread was opened from
+	notes     list.Model
+	truncated bool // the thread was too big to fetch in full
+}
+
+func newThreadScreen(m *model, noteID string) *threadScreen {
+	notes := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	notes.SetShowTitle(false)
+	notes.SetFilteringEnabled(false)
+	notes.DisableQuitKeybindings()
+	notes.SetStatusBarItemName("note", "notes")
+	notes.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			m.keys.Detail,
+			m.keys.Reply,
+			m.keys.React,
+			m.keys.Renote,
+			m.keys.Quote,
+			m.keys.ToggleCW,
+			m.keys.Profile,
+			m.keys.ListUser,
+			m.keys.ThreadQuit,
+		}
+	}
+	s := &threadScreen{noteID: noteID, notes: notes}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// openThread starts loading the thread note is part of, which is shown on
+// top of the current screen with the cursor on note once it has loaded.
+func (m *model) openThread(note *misskey.Note) tea.Cmd {
+	if note.Renote != nil && note.Text == "" {
+		note = note.Renote
+	}
+	m.resetViewContext()
+	m.loading = true
+	s := newThreadScreen(m, note.ID)
+	m.opening = s
+	return tea.Batch(m.spinner.Tick, m.fetchThreadCmd(s, note))
+}
+
+// fetchThread walks up from note to the root of its thread, then fetches the
+// replies to every note in it breadth first. Quotes are left out. truncated
+// is set when the thread was too big to fetch in full.
+func fetchThread(ctx context.Context, client *misskey.Client, note *misskey.Note) (root *threadNode, truncated bool, err error) {
+	var chain []misskey.Note
+	if note.ReplyId != "" {
+		ancestors, err := client.NoteConversation(ctx, misskey.NoteConversationRequest{NoteID: note.ID, Limit: threadPageSize})
+		if err != nil {
+			return nil, false, err
+		}
+		slices.Reverse(ancestors)
+		chain = ancestors
+	}
+	chain = append(chain, *note)
+	// The conversation stops short of the root of a long thread.
+	truncated = chain[0].ReplyId != ""
+
+	// The chain down to note is known already, so it is shown even when
+	// the thread is too big to fetch in full.
+	root = &threadNode{note: chain[0]}
+	nodes := map[string]*threadNode{root.note.ID: root}
+	for i := 1; i < len(chain); i++ {
+		n := &threadNode{note: chain[i]}
+		parent := nodes[chain[i-1].ID]
+		parent.replies = append(parent.replies, n)
+		nodes[n.note.ID] = n
+	}
+
+	queue := []*threadNode{root}
+	requests := 0
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.note.RepliesCount > 0 && requests == maxThreadRequests {
+			truncated = true
+		} else if n.note.RepliesCount > 0 {
+			requests++
+			children, err := client.NoteChildren(ctx, misskey.NoteChildrenRequest{NoteID: n.note.ID, Limit: threadPageSize})
+			if err != nil {
+				return nil, false, err
+			}
+			if len(children) == threadPageSize {
+				truncated = true
+			}
+			for _, child := range children {
+				if child.ReplyId != n.note.ID || nodes[child.ID] != nil {
+					continue
+				}
+				c := &threadNode{note: child}
+				n.replies = append(n.replies, c)
+				nodes[child.ID] = c
+			}
+			slices.SortFunc(n.replies, func(a, b *threadNode) int {
+				return strings.Compare(a.note.CreatedAt, b.note.CreatedAt)
+			})
+		}
+		queue = append(queue, n.replies...)
+	}
+	return root, truncated, nil
+}
+
+// threadItems flattens the thread into list items with tree guides. A lone
+// reply stays at the level of the note it replies to, so back-and-forth
+// conversations don't drift to the right; only branches are indented.
+func threadItems(root *threadNode) []list.Item {
+	var items []list.Item
+	var walk func(n *threadNode, lead, cont string)
+	walk = func(n *threadNode, lead, cont string) {
+		items = append(items, threadItem{item: item{note: n.note}, lead: lead, cont: cont})
+		if len(n.replies) == 1 {
+			walk(n.replies[0], cont, cont)
+			return
+		}
+		for i, reply := range n.replies {
+			if i == len(n.replies)-1 {
+				walk(reply, cont+"└─ ", cont+"   ")
+			} else {
+				walk(reply, cont+"├─ ", cont+"│  ")
+			}
+		}
+	}
+	walk(root, "", "")
+	return items
+}
+
+func (s *threadScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.notes.SetSize(width-h, height-v-3)
+}
+
+func (s *threadScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		selectedItem, selected := s.notes.SelectedItem().(threadItem)
+		switch {
+		case key.Matches(msg, m.keys.ThreadQuit):
+			m.resetViewContext()
+			m.pop()
+			return nil
+		case !selected:
+		case key.Matches(msg, m.keys.Detail):
+			return m.openDetail(&selectedItem.note)
+		case key.Matches(msg, m.keys.Reply):
+			return m.openComposer(&selectedItem.note)
+		case key.Matches(msg, m.keys.React):
+			return m.openReactionPicker(&selectedItem.note)
+		case key.Matches(msg, m.keys.Renote):
+			return m.createRenoteCmd(selectedItem.note.ID)
+		case key.Matches(msg, m.keys.Quote):
+			return m.openQuoteComposer(&selectedItem.note)
+		case key.Matches(msg, m.keys.ToggleCW):
+			if noteCW(&selectedItem.note) != "" {
+				selectedItem.expanded = !selectedItem.expanded
+				return s.notes.SetItem(s.notes.Index(), selectedItem)
+			}
+			return nil
+		case key.Matches(msg, m.keys.Profile):
+			return m.openUserProfile(selectedItem.note.User)
+		case key.Matches(msg, m.keys.ListUser):
+			return m.openListPicker(&selectedItem.note.User)
+		}
+	}
+
+	var cmd tea.Cmd
+	s.notes, cmd = s.notes.Update(msg)
+	return cmd
+}
+
+func (s *threadScreen) View(m *model) string {
+	header := activeTabStyle.Render("THREAD")
+	if s.truncated {
+		header += metadataStyle.Render(" Thread truncated: not every note could be loaded")
+	}
+	mainContent := docStyle.Render(s.notes.View())
+	return header + "\n" + mainContent + "\n" + m.statusBarView()
+}
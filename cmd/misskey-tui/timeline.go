@@ -0,0 +1,314 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
+)
+
+// timelineScreen is the timeline at the bottom of the stack. Along with the
+// notes, it keeps the tabs, searches and column layout opened from it.
+type timelineScreen struct {
+	list           list.Model
+	timeline       string            // "home", "local", "social", "global", "antenna:<id>", "channel:<id>" or "list:<id>"
+	loadingMore    bool              // fetching older/newer notes in the background
+	end            bool              // no older notes left
+	antenna        *misskey.Antenna  // the antenna last picked, shown in the tab bar
+	channel        *misskey.Channel  // the channel last opened, shown in the tab bar
+	userList       *misskey.UserList // the list last opened, shown in the tab bar
+	lastSearch     *searchScreen     // the note search last opened
+	lastUserSearch *userSearchScreen // the user search last opened
+	columns        *columnsScreen    // the column layout, once it has been opened
+}
+
+func newTimelineScreen(m *model, timeline string) *timelineScreen {
+	keys := m.keys
+	l := list.New([]list.Item{}, newListDelegate(), 0, 0)
+	l.SetShowTitle(false)
+	l.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keys.Post,
+			keys.Reply,
+			keys.React,
+			keys.Renote,
+			keys.Quote,
+			keys.Detail,
+			keys.Switch,
+			keys.LoadNewer,
+			keys.Notify,
+			keys.Accounts,
+			keys.ToggleCW,
+			keys.Profile,
+			keys.Search,
+			keys.FindUser,
+			keys.Antennas,
+			keys.Channels,
+			keys.Lists,
+			keys.ListUser,
+			keys.Thread,
+			keys.Columns,
+			keys.AddColumn,
+		}
+	}
+	s := &timelineScreen{list: l, timeline: timeline}
+	s.setSize(m.width, m.height)
+	return s
+}
+
+// root returns the timeline at the bottom of the stack.
+func (m *model) root() *timelineScreen {
+	return m.screens[0].(*timelineScreen)
+}
+
+func (s *timelineScreen) setSize(width, height int) {
+	h, v := docStyle.GetFrameSize()
+	s.list.SetSize(max(width-h, 0), max(height-v-3, 0))
+}
+
+func (s *timelineScreen) Update(m *model, msg tea.Msg) tea.Cmd {
+	if msg, ok := msg.(tea.KeyMsg); ok && s.list.FilterState() != list.Filtering {
+		selectedItem, selected := s.list.SelectedItem().(item)
+		switch {
+		case key.Matches(msg, m.keys.Quit):
+			return tea.Quit
+		case key.Matches(msg, m.keys.Post):
+			return m.openComposer(nil)
+		case key.Matches(msg, m.keys.Search):
+			return m.openSearch()
+		case key.Matches(msg, m.keys.FindUser):
+			return m.openUserSearch()
+		case key.Matches(msg, m.keys.Accounts):
+			m.push(newAccountsScreen(m))
+			return nil
+		case key.Matches(msg, m.keys.Notify):
+			ns := newNotificationsScreen(m)
+			m.push(ns)
+			m.resetViewContext()
+			m.loading = true
+			return tea.Batch(m.spinner.Tick, m.fetchNotificationsCmd(ns))
+		case key.Matches(msg, m.keys.Antennas):
+			as := newAntennasScreen(m)
+			m.push(as)
+			m.resetViewContext()
+			m.loading = true
+			return tea.Batch(m.spinner.Tick, m.fetchAntennasCmd(as))
+		case key.Matches(msg, m.keys.Channels):
+			cs := newChannelsScreen(m)
+			m.push(cs)
+			m.resetViewContext()
+			m.loading = true
+			return tea.Batch(m.spinner.Tick, m.fetchChannelsCmd(cs, cs.source))
+		case key.Matches(msg, m.keys.Lists):
+			return m.openListPicker(nil)
+		case key.Matches(msg, m.keys.Columns):
+			return m.openColumns()
+		case key.Matches(msg, m.keys.AddColumn):
+			return m.addColumn(ColumnConfig{Source: s.timeline, Title: s.title()})
+		case key.Matches(msg, m.keys.Switch):
+			timelineMap := map[string]string{"h": "home", "l": "local", "s": "social", "g": "global"}
+			return s.switchTimeline(m, timelineMap[msg.String()])
+		case key.Matches(msg, m.keys.LoadNewer):
+			return s.loadNewerNotes(m)
+		case !selected:
+		case key.Matches(msg, m.keys.Reply):
+			return m.openComposer(&selectedItem.note)
+		case key.Matches(msg, m.keys.Thread):
+			return m.openThread(&selectedItem.note)
+		case key.Matches(msg, m.keys.ToggleCW):
+			if noteCW(&selectedItem.note) != "" {
+				selectedItem.expanded = !selectedItem.expanded
+				return s.list.SetItem(s.list.Index(), selectedItem)
+			}
+			return nil
+		case key.Matches(msg, m.keys.React):
+			return m.openReactionPicker(&selectedItem.note)
+		case key.Matches(msg, m.keys.Renote):
+			return m.createRenoteCmd(selectedItem.note.ID)
+		case key.Matches(msg, m.keys.Quote):
+			return m.openQuoteComposer(&selectedItem.note)
+		case key.Matches(msg, m.keys.Profile):
+			note := &selectedItem.note
+			if note.Renote != nil && note.Text == "" {
+				note = note.Renote
+			}
+			return m.openUserProfile(note.User)
+		case key.Matches(msg, m.keys.ListUser):
+			note := &selectedItem.note
+			if note.Renote != nil && note.Text == "" {
+				note = note.Renote
+			}
+			return m.openListPicker(&note.User)
+		case key.Matches(msg, m.keys.Detail):
+			return m.openDetail(&selectedItem.note)
+		}
+	}
+
+	var cmd tea.Cmd
+	s.list, cmd = s.list.Update(msg)
+	return tea.Batch(cmd, s.loadOlderIfAtBottom(m))
+}
+
+func (s *timelineScreen) View(m *model) string {
+	timelineTabs := []string{"home", "local", "social", "global"}
+	var renderedTabs []string
+	for _, t := range timelineTabs {
+		var style lipgloss.Style
+		if t == s.timeline {
+			style = activeTabStyle
+		} else {
+			style = inactiveTabStyle
+		}
+		renderedTabs = append(renderedTabs, style.Render(strings.ToTitle(t)))
+	}
+	if s.antenna != nil {
+		style := inactiveTabStyle
+		if s.timeline == antennaTimeline(s.antenna.ID) {
+			style = activeTabStyle
+		}
+		renderedTabs = append(renderedTabs, style.Render("ANTENNA: "+s.antenna.Name))
+	}
+	if s.channel != nil {
+		style := inactiveTabStyle
+		if s.timeline == channelTimeline(s.channel.ID) {
+			style = activeTabStyle
+		}
+		renderedTabs = append(renderedTabs, style.Render("CHANNEL: "+s.channel.Name))
+	}
+	if s.userList != nil {
+		style := inactiveTabStyle
+		if s.timeline == listTimeline(s.userList.ID) {
+			style = activeTabStyle
+		}
+		renderedTabs = append(renderedTabs, style.Render("LIST: "+s.userList.Name))
+	}
+	tabHeader := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
+
+	mainContent := docStyle.Render(s.list.View())
+
+	status := m.statusBarView()
+
+	return tabHeader + "\n" + mainContent + "\n" + status
+}
+
+// title is the title of the current timeline, as shown in its tab.
+func (s *timelineScreen) title() string {
+	switch {
+	case s.antenna != nil && s.timeline == antennaTimeline(s.antenna.ID):
+		return "ANTENNA: " + s.antenna.Name
+	case s.channel != nil && s.timeline == channelTimeline(s.channel.ID):
+		return "CHANNEL: " + s.channel.Name
+	case s.userList != nil && s.timeline == listTimeline(s.userList.ID):
+		return "LIST: " + s.userList.Name
+	}
+	return strings.ToTitle(s.timeline)
+}
+
+func (s *timelineScreen) noteIDs() map[string]bool {
+	ids := make(map[string]bool, len(s.list.Items()))
+	for _, listItem := range s.list.Items() {
+		if i, ok := listItem.(item); ok {
+			ids[i.note.ID] = true
+		}
+	}
+	return ids
+}
+
+// appendNotes adds notes missing from the list to its end and returns how
+// many were added.
+func (s *timelineScreen) appendNotes(notes []misskey.Note) int {
+	ids := s.noteIDs()
+	items := s.list.Items()
+	added := 0
+	for _, note := range notes {
+		if ids[note.ID] {
+			continue
+		}
+		ids[note.ID] = true
+		items = append(items, item{note: note})
+		added++
+	}
+	if added > 0 {
+		s.list.SetItems(items)
+	}
+	return added
+}
+
+// prependNotes adds notes missing from the list to its top, keeping the
+// cursor on the note the user was looking at unless it was already at the top.
+func (s *timelineScreen) prependNotes(notes []misskey.Note) int {
+	ids := s.noteIDs()
+	var newItems []list.Item
+	for _, note := range notes {
+		if ids[note.ID] {
+			continue
+		}
+		ids[note.ID] = true
+		newItems = append(newItems, item{note: note})
+	}
+	if len(newItems) == 0 {
+		return 0
+	}
+
+	index := s.list.Index()
+	s.list.SetItems(append(newItems, s.list.Items()...))
+	if index > 0 {
+		s.list.Select(index + len(newItems))
+	}
+	return len(newItems)
+}
+
+// loadOlderIfAtBottom fetches the next page of older notes once the cursor
+// reaches the last item of the timeline.
+func (s *timelineScreen) loadOlderIfAtBottom(m *model) tea.Cmd {
+	items := s.list.Items()
+	if s.loadingMore || s.end || len(items) == 0 || s.list.FilterState() != list.Unfiltered {
+		return nil
+	}
+	if s.list.Index() < len(items)-1 {
+		return nil
+	}
+	last, ok := items[len(items)-1].(item)
+	if !ok {
+		return nil
+	}
+	s.loadingMore = true
+	m.statusMessage = "Loading older notes..."
+	return m.fetchOlderNotesCmd(s, last.note.ID)
+}
+
+// loadNewerNotes fetches the notes newer than the top of the timeline, or
+// reloads it if it is empty.
+func (s *timelineScreen) loadNewerNotes(m *model) tea.Cmd {
+	if s.loadingMore {
+		return nil
+	}
+	if len(s.list.Items()) == 0 {
+		m.loading = true
+		return tea.Batch(m.spinner.Tick, m.fetchTimelineCmd(s))
+	}
+	first, ok := s.list.Items()[0].(item)
+	if !ok {
+		return nil
+	}
+	s.loadingMore = true
+	m.statusMessage = "Loading newer notes..."
+	return m.fetchNewerNotesCmd(s, first.note.ID)
+}
+
+// switchTimeline shows timeline instead of the current one, moving the
+// stream over to it.
+func (s *timelineScreen) switchTimeline(m *model, timeline string) tea.Cmd {
+	if s.timeline == timeline {
+		return nil
+	}
+	s.timeline = timeline
+	m.stream.subscribe(m.streamTimelines()...)
+	m.resetTimelineContext()
+	m.loading = true
+	s.loadingMore = false
+	return tea.Batch(m.spinner.Tick, m.fetchTimelineCmd(s))
+}
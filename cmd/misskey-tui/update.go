@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"maps"
 	"slices"
@@ -10,18 +11,175 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
 )
 
 // --- Messages ---
 
-type timelineLoadedMsg struct{ items []list.Item }
-type parentNoteLoadedMsg struct{ note *Note }
-type childrenNotesLoadedMsg struct{ notes []Note }
-type notePostedMsg struct{ err error }
+type timelineLoadedMsg struct {
+	screen *timelineScreen
+	items  []list.Item
+}
+type parentNoteLoadedMsg struct {
+	screen *detailScreen
+	note   *misskey.Note
+}
+type childrenNotesLoadedMsg struct {
+	screen *detailScreen
+	notes  []misskey.Note
+}
+type threadLoadedMsg struct {
+	screen    *threadScreen
+	root      *threadNode
+	truncated bool
+}
+type olderNotesLoadedMsg struct {
+	screen   *timelineScreen
+	timeline string
+	notes    []misskey.Note
+	err      error
+}
+type newerNotesLoadedMsg struct {
+	screen   *timelineScreen
+	timeline string
+	notes    []misskey.Note
+	more     bool // stopped at maxNewerPages with newer notes left
+	err      error
+}
+type notePostedMsg struct {
+	composer *postingScreen
+	err      error
+}
 type noteRenotedMsg struct{ err error }
-type reactionResultMsg struct{ err error }
+type reactionResultMsg struct {
+	noteId   string
+	reaction string
+	err      error
+}
+type reactionDeletedMsg struct {
+	noteId string
+	err    error
+}
+type emojisLoadedMsg struct {
+	client *misskey.Client
+	items  []list.Item
+	err    error
+}
+type pollVotedMsg struct {
+	noteId string
+	choice int
+	err    error
+}
+type fileUploadedMsg struct {
+	composer *postingScreen
+	file     *misskey.DriveFile
+	err      error
+}
+type driveFileUpdatedMsg struct {
+	composer *postingScreen
+	file     *misskey.DriveFile
+	err      error
+}
+type driveFilesLoadedMsg struct {
+	screen *driveScreen
+	files  []misskey.DriveFile
+}
+type recipientsFoundMsg struct {
+	screen *recipientsScreen
+	users  []misskey.User
+	err    error
+}
+type recipientsLoadedMsg struct {
+	composer *postingScreen
+	users    []misskey.User
+	err      error
+}
+type notificationsLoadedMsg struct {
+	screen *notificationsScreen
+	items  []list.Item
+}
+type antennasLoadedMsg struct {
+	screen   *antennasScreen
+	antennas []misskey.Antenna
+}
+type channelsLoadedMsg struct {
+	screen   *channelsScreen
+	source   string
+	channels []misskey.Channel
+}
+type channelFollowedMsg struct {
+	channel misskey.Channel // with IsFollowing updated
+	err     error
+}
+type userListsLoadedMsg struct {
+	screen *listsScreen
+	lists  []misskey.UserList
+}
+type listMembershipMsg struct {
+	list  misskey.UserList // with UserIDs updated
+	user  misskey.User
+	added bool
+	err   error
+}
+type profileLoadedMsg struct {
+	screen *profileScreen
+	user   *misskey.UserDetail
+	notes  []misskey.Note
+}
+type profileNotesLoadedMsg struct {
+	screen *profileScreen
+	notes  []misskey.Note
+	err    error
+}
+type searchResultsMsg struct {
+	screen  *searchScreen
+	query   string
+	untilID string // set when loading more results
+	notes   []misskey.Note
+	err     error // only set when loading more results
+}
+type userSearchResultsMsg struct {
+	screen *userSearchScreen
+	query  string
+	offset int // set when loading more results
+	users  []misskey.User
+	note   *misskey.Note // set when the query was the URL of a note
+	paged  bool          // more results can be loaded by offset
+	err    error         // only set when loading more results
+}
+type followedMsg struct {
+	userID string
+	action string // "follow", "unfollow" or "cancel"
+	err    error
+}
+type columnLoadedMsg struct {
+	column  *column
+	sinceID string // set when loading newer items
+	untilID string // set when loading older items
+	items   []list.Item
+	err     error
+}
+type imageLoadedMsg struct{ key string }
 type clearStatusMsg struct{}
+type streamNoteMsg struct {
+	stream   *stream
+	timeline string
+	note     misskey.Note
+}
+type streamNotificationMsg struct {
+	stream       *stream
+	notification misskey.Notification
+}
+type streamStatusMsg struct {
+	stream    *stream
+	connected bool
+	err       error
+}
+type accountSwitchedMsg struct {
+	account *Account
+	client  *misskey.Client
+	user    *misskey.User
+}
 type errorMsg struct{ err error }
 
 func (e errorMsg) Error() string { return e.err.Error() }
@@ -36,6 +194,11 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.onWindowSizeChanged(msg)
 		m.help.Width = msg.Width
+		m.cell = terminalCellSize()
+		return m, nil
+
+	case imageLoadedMsg:
+		// The detail view picks the image up from the cache.
 		return m, nil
 
 	case tea.KeyMsg:
@@ -43,198 +206,455 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.err = nil
 			return m, nil
 		}
-
-		switch m.mode {
-		case "timeline":
-			if m.loading {
-				return m, nil
-			}
-			if m.list.FilterState() == list.Filtering {
-				break
-			}
+		if m.loading {
 			switch {
-			case key.Matches(msg, m.keys.Quit):
+			case key.Matches(msg, m.keys.LoadingQuit):
 				return m, tea.Quit
-			case key.Matches(msg, m.keys.Post):
-				m.mode = "posting"
-				m.textarea.Placeholder = "What's on your mind?"
-				return m, m.textarea.Focus()
-			case key.Matches(msg, m.keys.Reply):
-				if selectedItem, ok := m.list.SelectedItem().(item); ok {
-					m.mode = "posting"
-					m.replyToId = selectedItem.note.ID
-					m.replyToNote = &selectedItem.note
-					m.textarea.Placeholder = fmt.Sprintf("Replying to @%s...", selectedItem.note.User.Username)
-					return m, m.textarea.Focus()
-				}
-			case key.Matches(msg, m.keys.React):
-				if selectedItem, ok := m.list.SelectedItem().(item); ok {
-					cmds = append(cmds, m.createReactionCmd(selectedItem.note.ID, "❤️"))
-				}
-			case key.Matches(msg, m.keys.Renote):
-				if selectedItem, ok := m.list.SelectedItem().(item); ok {
-					cmds = append(cmds, m.createRenoteCmd(selectedItem.note.ID))
-				}
-			case key.Matches(msg, m.keys.Detail):
-				if selectedItem, ok := m.list.SelectedItem().(item); ok {
-					m.loading = true
-					m.selectedNote = &selectedItem.note
-
-					// Use target note for children/parent fetching (handle Renote)
-					targetNote := m.selectedNote
-					if targetNote.Renote != nil && targetNote.Text == "" {
-						targetNote = targetNote.Renote
-					}
-
-					var batchCmds []tea.Cmd
-					batchCmds = append(batchCmds, m.spinner.Tick, m.fetchNoteChildrenCmd(targetNote.ID))
-					if targetNote.ReplyId != "" {
-						batchCmds = append(batchCmds, m.fetchParentNoteCmd(targetNote.ReplyId))
-					}
-					cmds = append(cmds, tea.Batch(batchCmds...))
-				}
-			case key.Matches(msg, m.keys.Switch):
-				key := msg.String()
-				timelineMap := map[string]string{"h": "home", "l": "local", "s": "social", "g": "global"}
-				if m.timeline != timelineMap[key] {
-					m.timeline = timelineMap[key]
-					m.loading = true
-					cmds = append(cmds, m.spinner.Tick, m.fetchTimelineCmd())
-				}
+			case key.Matches(msg, m.keys.LoadingCancel):
+				m.cancelLoading()
 			}
-		case "posting":
-			switch {
-			case key.Matches(msg, m.keys.PostSubmit):
-				m.loading = true
-				cmds = append(cmds, m.spinner.Tick, m.createNoteCmd(m.textarea.Value(), m.replyToId))
-				return m, tea.Batch(cmds...)
-			case key.Matches(msg, m.keys.PostCancel):
-				m.mode = "timeline"
-				m.textarea.Reset()
-				m.replyToId = ""
-				m.replyToNote = nil
-				return m, nil
-			}
-		case "detail":
-			switch {
-			case key.Matches(msg, m.keys.DetailQuit):
-				m.mode = "timeline"
-				m.selectedNote = nil
-				m.parentNote = nil
-				return m, nil
-			case key.Matches(msg, m.keys.DetailReply):
-				m.mode = "posting"
-				m.replyToId = m.selectedNote.ID
-				m.replyToNote = m.selectedNote
-				m.textarea.Placeholder = fmt.Sprintf("Replying to @%s...", m.selectedNote.User.Username)
-				return m, m.textarea.Focus()
-			case key.Matches(msg, m.keys.DetailReact):
-				cmds = append(cmds, m.createReactionCmd(m.selectedNote.ID, "❤️"))
-			case key.Matches(msg, m.keys.DetailRenote):
-				cmds = append(cmds, m.createRenoteCmd(m.selectedNote.ID))
-			case msg.String() == "tab":
-				if m.detailFocus == "note" {
-					m.detailFocus = "replies"
-				} else {
-					m.detailFocus = "note"
+			return m, nil
+		}
+		return m, m.top().Update(m, msg)
+
+	case timelineLoadedMsg:
+		if msg.screen != m.root() {
+			return m, nil
+		}
+		m.loading = false
+		msg.screen.end = false
+		msg.screen.list.SetItems(msg.items)
+
+	case olderNotesLoadedMsg:
+		s := msg.screen
+		s.loadingMore = false
+		m.statusMessage = ""
+		if msg.err != nil {
+			return m, m.loadMoreFailed(msg.err)
+		}
+		if s != m.root() || msg.timeline != s.timeline {
+			return m, nil
+		}
+		if s.appendNotes(msg.notes) == 0 {
+			s.end = true
+		}
+		return m, nil
+
+	case newerNotesLoadedMsg:
+		s := msg.screen
+		s.loadingMore = false
+		if msg.err != nil {
+			m.statusMessage = ""
+			return m, m.loadMoreFailed(msg.err)
+		}
+		if s != m.root() || msg.timeline != s.timeline {
+			m.statusMessage = ""
+			return m, nil
+		}
+		// sinceId results may come back oldest first.
+		slices.SortStableFunc(msg.notes, func(a, b misskey.Note) int {
+			return strings.Compare(b.CreatedAt, a.CreatedAt)
+		})
+		if added := s.prependNotes(msg.notes); added > 0 && msg.more {
+			m.statusMessage = fmt.Sprintf("Loaded %d newer notes; press n for more", added)
+		} else if added > 0 {
+			m.statusMessage = fmt.Sprintf("Loaded %d newer notes", added)
+		} else {
+			m.statusMessage = "No newer notes"
+		}
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+
+	case accountSwitchedMsg:
+		m.account = msg.account
+		m.client = msg.client
+		m.userID = msg.user.ID
+		m.username = msg.user.Username
+		m.hostname = msg.client.Host()
+
+		m.emojisLoaded = false
+		m.emojis = nil
+
+		// Antennas, channels, lists, search results and columns belong to
+		// the previous account's server, so the timeline starts afresh.
+		m.stream.close()
+		m.closeColumns()
+		timeline := m.root().timeline
+		if _, ok := timelineKinds[timeline]; !ok {
+			timeline = "home"
+		}
+		m.screens = []screen{newTimelineScreen(m, timeline)}
+
+		m.stream = newStream(msg.client, m.streamTimelines()...)
+		go m.stream.run()
+		m.streaming = false
+
+		m.resetTimelineContext()
+		m.statusMessage = fmt.Sprintf("Switched to %s", msg.account.Name)
+		cmds = append(cmds,
+			m.spinner.Tick,
+			m.fetchTimelineCmd(m.root()),
+			m.waitForStreamCmd(),
+			tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} }),
+		)
+
+	case fileUploadedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to upload: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		m.statusMessage = fmt.Sprintf("Uploaded %s", msg.file.Name)
+		if !m.onStack(msg.composer) {
+			// The file stays in the drive for a later note.
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		msg.composer.attachments = append(msg.composer.attachments, *msg.file)
+		m.refreshAttachments(msg.composer)
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+
+	case driveFileUpdatedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to update file: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		m.statusMessage = fmt.Sprintf("Updated %s", msg.file.Name)
+		if c := msg.composer; m.onStack(c) {
+			for i := range c.attachments {
+				if c.attachments[i].ID == msg.file.ID {
+					c.attachments[i] = *msg.file
 				}
 			}
+			m.refreshAttachments(c)
 		}
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 
-	case timelineLoadedMsg:
+	case driveFilesLoadedMsg:
+		if !m.onStack(msg.screen) {
+			return m, nil
+		}
 		m.loading = false
-		m.list.SetItems(msg.items)
+		s := msg.screen
+		items := make([]list.Item, len(msg.files))
+		for i, file := range msg.files {
+			items[i] = driveFileItem{file: file, selected: s.composer.isAttached(file.ID)}
+		}
+		s.list.SetItems(items)
 
-	case parentNoteLoadedMsg:
-		m.parentNote = msg.note
+	case recipientsFoundMsg:
+		if abandoned(msg.err) {
+			return m, nil
+		}
+		if !m.onStack(msg.screen) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to search users: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		m.statusMessage = ""
+		if len(msg.users) == 0 {
+			m.statusMessage = "No users found"
+		}
+		s := msg.screen
+		s.list.SetItems(s.composer.recipientItems(msg.users))
+		s.list.ResetSelected()
+		s.input.Blur()
 		return m, nil
 
-	case childrenNotesLoadedMsg:
+	case recipientsLoadedMsg:
+		if abandoned(msg.err) {
+			return m, nil
+		}
+		if !m.onStack(msg.composer) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load recipients: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		for _, user := range msg.users {
+			if !msg.composer.isRecipient(user.ID) && user.ID != m.userID {
+				msg.composer.recipients = append(msg.composer.recipients, user)
+			}
+		}
+		return m, nil
+
+	case notificationsLoadedMsg:
+		if !m.onStack(msg.screen) {
+			return m, nil
+		}
 		m.loading = false
-		var items []list.Item
-		for _, note := range msg.notes {
-			items = append(items, item{note: note})
+		msg.screen.list.SetItems(msg.items)
+		msg.screen.list.ResetSelected()
+
+	case antennasLoadedMsg:
+		if !m.onStack(msg.screen) {
+			return m, nil
+		}
+		m.loading = false
+		items := make([]list.Item, len(msg.antennas))
+		for i, antenna := range msg.antennas {
+			items[i] = antennaItem{antenna: antenna, current: m.root().timeline == antennaTimeline(antenna.ID)}
+		}
+		msg.screen.list.SetItems(items)
+		msg.screen.list.ResetSelected()
+		if len(items) == 0 {
+			m.pop()
+			m.statusMessage = "You have no antennas"
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 		}
-		m.detailList.SetItems(items)
-		m.mode = "detail"
-		m.detailFocus = "note"
 
-		// Initialize viewport content
-		displayNote := m.selectedNote
-		if displayNote.Renote != nil && displayNote.Text == "" {
-			displayNote = displayNote.Renote
+	case channelsLoadedMsg:
+		if !m.onStack(msg.screen) || msg.source != msg.screen.source {
+			return m, nil
 		}
+		m.loading = false
+		items := make([]list.Item, len(msg.channels))
+		for i, channel := range msg.channels {
+			items[i] = channelItem{channel: channel}
+		}
+		msg.screen.list.SetItems(items)
+		msg.screen.list.ResetSelected()
 
-		var noteContent strings.Builder
-		if m.selectedNote.Renote != nil && m.selectedNote.Text == "" {
-			renoterName := m.selectedNote.User.Name
-			if renoterName == "" {
-				renoterName = m.selectedNote.User.Username
+	case channelFollowedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to update channel: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		for _, s := range m.screens {
+			s, ok := s.(*channelsScreen)
+			if !ok {
+				continue
 			}
-			noteContent.WriteString(metadataStyle.Render(fmt.Sprintf("Renoted by %s", renoterName)))
-			noteContent.WriteString("\n")
-		}
-
-		noteContent.WriteString(lipgloss.NewStyle().Bold(true).Render(item{note: *displayNote}.Title()))
-		noteContent.WriteString("\n\n")
-		noteContent.WriteString(displayNote.Text)
-		noteContent.WriteString("\n\n")
-
-		// Metadata
-		heartCount := 0
-		otherReactions := []string{}
-		for _, r := range slices.Sorted(maps.Keys(displayNote.Reactions)) {
-			isCustomEmoji := strings.HasPrefix(r, ":") && strings.HasSuffix(r, ":")
-			if r == "❤️" || isCustomEmoji {
-				heartCount += displayNote.Reactions[r]
-			} else {
-				otherReactions = append(otherReactions, fmt.Sprintf("%s %d", r, displayNote.Reactions[r]))
+			for i, listItem := range s.list.Items() {
+				if it, ok := listItem.(channelItem); ok && it.channel.ID == msg.channel.ID {
+					s.list.SetItem(i, channelItem{channel: msg.channel})
+				}
 			}
 		}
+		if root := m.root(); root.channel != nil && root.channel.ID == msg.channel.ID {
+			channel := msg.channel
+			root.channel = &channel
+		}
+		if msg.channel.IsFollowing {
+			m.statusMessage = fmt.Sprintf("Followed %s", msg.channel.Name)
+		} else {
+			m.statusMessage = fmt.Sprintf("Unfollowed %s", msg.channel.Name)
+		}
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 
-		var reactions []string
-		if heartCount > 0 {
-			reactions = append(reactions, fmt.Sprintf("❤️ %d", heartCount))
+	case userListsLoadedMsg:
+		if !m.onStack(msg.screen) {
+			return m, nil
+		}
+		m.loading = false
+		items := make([]list.Item, len(msg.lists))
+		for i, userList := range msg.lists {
+			items[i] = userListItem{list: userList, member: msg.screen.member, current: m.root().timeline == listTimeline(userList.ID)}
+		}
+		msg.screen.list.SetItems(items)
+		msg.screen.list.ResetSelected()
+		if len(items) == 0 {
+			m.pop()
+			m.statusMessage = "You have no lists"
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 		}
-		reactions = append(reactions, otherReactions...)
-		reactionsStr := strings.Join(reactions, " | ")
 
-		t, err := time.Parse(time.RFC3339, displayNote.CreatedAt)
-		var timeStr string
-		if err == nil {
-			timeStr = t.Local().Format("2006-01-02 15:04:05")
+	case listMembershipMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to update list: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 		}
+		for _, s := range m.screens {
+			s, ok := s.(*listsScreen)
+			if !ok {
+				continue
+			}
+			for i, listItem := range s.list.Items() {
+				if it, ok := listItem.(userListItem); ok && it.list.ID == msg.list.ID {
+					it.list = msg.list
+					s.list.SetItem(i, it)
+				}
+			}
+		}
+		if root := m.root(); root.userList != nil && root.userList.ID == msg.list.ID {
+			userList := msg.list
+			root.userList = &userList
+		}
+		if msg.added {
+			m.statusMessage = fmt.Sprintf("Added @%s to %s", acct(msg.user), msg.list.Name)
+		} else {
+			m.statusMessage = fmt.Sprintf("Removed @%s from %s", acct(msg.user), msg.list.Name)
+		}
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
 
-		countsStr := fmt.Sprintf("Replies: %d, Renotes: %d", displayNote.RepliesCount, displayNote.RenoteCount)
+	case parentNoteLoadedMsg:
+		msg.screen.parent = msg.note
+		return m, nil
 
-		metaData := lipgloss.JoinVertical(lipgloss.Left,
-			reactionsStr,
-			metadataStyle.Render(countsStr),
-			metadataStyle.Render(timeStr),
-		)
-		noteContent.WriteString(metaData)
+	case profileLoadedMsg:
+		if !m.opened(msg.screen) {
+			return m, nil
+		}
+		m.loading = false
+		s := msg.screen
+		s.user = msg.user
+		s.end = appendNoteItems(&s.notes, msg.notes) == 0
+		m.push(s)
+		return m, nil
 
-		m.viewport.SetContent(noteContent.String())
-		m.viewport.YOffset = 0
+	case profileNotesLoadedMsg:
+		msg.screen.loadingMore = false
+		m.statusMessage = ""
+		if msg.err != nil {
+			return m, m.loadMoreFailed(msg.err)
+		}
+		msg.screen.end = appendNoteItems(&msg.screen.notes, msg.notes) == 0
+		return m, nil
+
+	case searchResultsMsg:
+		s := msg.screen
+		if msg.untilID != "" {
+			s.loadingMore = false
+			m.statusMessage = ""
+		}
+		if msg.err != nil {
+			return m, m.loadMoreFailed(msg.err)
+		}
+		if msg.query != s.query {
+			return m, nil
+		}
+		if msg.untilID != "" {
+			s.end = appendNoteItems(&s.results, msg.notes) == 0
+			return m, nil
+		}
+		m.loading = false
+		s.results.SetItems(nil)
+		s.results.ResetSelected()
+		s.end = appendNoteItems(&s.results, msg.notes) == 0
+		if s.end {
+			m.statusMessage = fmt.Sprintf("No notes found for %q", msg.query)
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		s.input.Blur()
+		return m, nil
+
+	case userSearchResultsMsg:
+		s := msg.screen
+		if msg.offset > 0 {
+			s.loadingMore = false
+			m.statusMessage = ""
+		}
+		if msg.err != nil {
+			return m, m.loadMoreFailed(msg.err)
+		}
+		if msg.query != s.query {
+			return m, nil
+		}
+		if msg.offset > 0 {
+			for _, user := range msg.users {
+				s.results.InsertItem(len(s.results.Items()), userItem{user: user})
+			}
+			s.end = len(msg.users) < timelinePageSize
+			return m, nil
+		}
+		m.loading = false
+		if msg.note != nil {
+			return m, m.openDetail(msg.note)
+		}
+		items := make([]list.Item, len(msg.users))
+		for i, user := range msg.users {
+			items[i] = userItem{user: user}
+		}
+		s.results.SetItems(items)
+		s.results.ResetSelected()
+		s.end = !msg.paged || len(msg.users) < timelinePageSize
+		if len(items) == 0 {
+			m.statusMessage = fmt.Sprintf("No users found for %q", msg.query)
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		s.input.Blur()
+		return m, nil
+
+	case followedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to %s: %s", msg.action, describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		for _, s := range m.screens {
+			profile, ok := s.(*profileScreen)
+			if !ok || profile.user.ID != msg.userID {
+				continue
+			}
+			user := profile.user
+			switch msg.action {
+			case "follow":
+				if user.IsLocked {
+					user.HasPendingFollowRequestFromYou = true
+				} else {
+					user.IsFollowing = true
+					user.FollowersCount++
+				}
+			case "unfollow":
+				user.IsFollowing = false
+				user.FollowersCount = max(user.FollowersCount-1, 0)
+			case "cancel":
+				user.HasPendingFollowRequestFromYou = false
+			}
+			m.statusMessage = followStatus(user, msg.action)
+		}
+		return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+
+	case childrenNotesLoadedMsg:
+		if !m.opened(msg.screen) {
+			return m, nil
+		}
+		m.loading = false
+		s := msg.screen
+		var items []list.Item
+		for _, note := range msg.notes {
+			items = append(items, item{note: note})
+		}
+		s.replies.SetItems(items)
+		s.viewport.SetContent(s.content())
+		m.push(s)
+		return m, nil
+
+	case threadLoadedMsg:
+		if !m.opened(msg.screen) {
+			return m, nil
+		}
+		m.loading = false
+		s := msg.screen
+		s.truncated = msg.truncated
+		items := threadItems(msg.root)
+		s.notes.SetItems(items)
+		for i, listItem := range items {
+			if listItem.(threadItem).note.ID == s.noteID {
+				s.notes.Select(i)
+			}
+		}
+		m.push(s)
+		return m, nil
 
 	case notePostedMsg:
 		m.loading = false
-		m.mode = "timeline"
-		m.textarea.Reset()
-		m.replyToId = ""
-		m.replyToNote = nil
 		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Failed to post note: %v", msg.err)
+			// Keep the composer open so that nothing has to be entered again.
+			m.statusMessage = fmt.Sprintf("Failed to post note: %s", describeError(msg.err))
 		} else {
+			m.closeComposer(msg.composer)
+			// Unless the stream brings the new note, fetch it into the
+			// timeline underneath rather than reloading it.
+			if root := m.root(); !m.stream.streams(root.timeline) {
+				cmds = append(cmds, root.loadNewerNotes(m))
+			}
 			m.statusMessage = "Note posted successfully!"
-			m.loading = true
-			cmds = append(cmds, m.spinner.Tick, m.fetchTimelineCmd())
 		}
 		cmds = append(cmds, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} }))
 
 	case noteRenotedMsg:
 		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Failed to renote: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Failed to renote: %s", describeError(msg.err))
 		} else {
 			m.statusMessage = "Renoted successfully!"
 		}
@@ -242,16 +662,136 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case reactionResultMsg:
 		if msg.err != nil {
-			m.statusMessage = fmt.Sprintf("Failed to react: %v", msg.err)
+			m.statusMessage = fmt.Sprintf("Failed to react: %s", describeError(msg.err))
 		} else {
-			m.statusMessage = "Reacted with ❤️"
+			m.statusMessage = fmt.Sprintf("Reacted with %s", reactionLabel(msg.reaction))
+			m.updateNote(msg.noteId, func(note *misskey.Note) {
+				removeMyReaction(note)
+				if note.Reactions == nil {
+					note.Reactions = map[string]int{}
+				}
+				key := reactionKey(msg.reaction)
+				note.Reactions[key]++
+				note.MyReaction = key
+			})
 		}
 		cmds = append(cmds, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} }))
 
+	case pollVotedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to vote: %s", describeError(msg.err))
+		} else {
+			m.statusMessage = "Voted!"
+			m.updateNote(msg.noteId, func(note *misskey.Note) {
+				if note.Poll != nil && msg.choice < len(note.Poll.Choices) {
+					note.Poll.Choices[msg.choice].Votes++
+					note.Poll.Choices[msg.choice].IsVoted = true
+				}
+			})
+		}
+		cmds = append(cmds, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} }))
+
+	case reactionDeletedMsg:
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to remove reaction: %s", describeError(msg.err))
+		} else {
+			m.statusMessage = "Reaction removed"
+			m.updateNote(msg.noteId, removeMyReaction)
+		}
+		cmds = append(cmds, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} }))
+
+	case emojisLoadedMsg:
+		if msg.client != m.client {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.statusMessage = fmt.Sprintf("Failed to load custom emoji: %s", describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		m.emojisLoaded = true
+		m.emojis = msg.items
+		for _, s := range m.screens {
+			if s, ok := s.(*reactionScreen); ok {
+				s.list.SetItems(msg.items)
+			}
+		}
+		return m, nil
+
 	case clearStatusMsg:
 		m.statusMessage = ""
 
+	case streamNoteMsg:
+		// Drop events from a stream replaced by an account switch.
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		if root := m.root(); msg.timeline == root.timeline {
+			root.prependNotes([]misskey.Note{msg.note})
+		}
+		for _, col := range m.columns() {
+			if col.Source == msg.timeline && !col.loading {
+				addColumnItems(col, []list.Item{item{note: msg.note}}, true)
+			}
+		}
+		return m, m.waitForStreamCmd()
+
+	case streamNotificationMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		for _, col := range m.columns() {
+			if col.Source == notificationsTimeline && !col.loading {
+				addColumnItems(col, []list.Item{notificationItem{notification: msg.notification}}, true)
+			}
+		}
+		return m, m.waitForStreamCmd()
+
+	case columnLoadedMsg:
+		col := msg.column
+		if !slices.Contains(m.columns(), col) {
+			return m, nil
+		}
+		if msg.err != nil {
+			col.loading = false
+			col.loadingMore = false
+			if abandoned(msg.err) {
+				return m, nil
+			}
+			m.statusMessage = fmt.Sprintf("Failed to load %s: %s", col.Title, describeError(msg.err))
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		}
+		switch {
+		case msg.untilID != "":
+			col.loadingMore = false
+			col.end = len(msg.items) == 0
+			addColumnItems(col, msg.items, false)
+		case msg.sinceID != "":
+			col.loadingMore = false
+			if added := addColumnItems(col, msg.items, true); added > 0 {
+				m.statusMessage = fmt.Sprintf("Loaded %d newer items in %s", added, col.Title)
+			} else {
+				m.statusMessage = fmt.Sprintf("Nothing newer in %s", col.Title)
+			}
+			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+		default:
+			col.loading = false
+			col.end = false
+			col.list.SetItems(msg.items)
+			col.list.ResetSelected()
+		}
+		return m, nil
+
+	case streamStatusMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		m.streaming = msg.connected
+		return m, m.waitForStreamCmd()
+
 	case errorMsg:
+		if abandoned(msg.err) {
+			return m, nil
+		}
 		m.loading = false
 		m.err = msg.err
 	}
@@ -260,36 +800,192 @@ func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.spinner, cmd = m.spinner.Update(msg)
 		cmds = append(cmds, cmd)
 	} else {
-		switch m.mode {
-		case "timeline":
-			m.list, cmd = m.list.Update(msg)
-			cmds = append(cmds, cmd)
-		case "posting":
-			m.textarea, cmd = m.textarea.Update(msg)
-			m.help, cmd = m.help.Update(msg)
-			cmds = append(cmds, cmd)
-		case "detail":
-			if m.detailFocus == "note" {
-				m.viewport, cmd = m.viewport.Update(msg)
-			} else {
-				m.detailList, cmd = m.detailList.Update(msg)
+		cmds = append(cmds, m.top().Update(m, msg))
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// updateNote applies update to every displayed copy of the note with id,
+// including renoted notes, and refreshes the detail views.
+func (m *model) updateNote(id string, update func(*misskey.Note)) {
+	seen := map[*misskey.Note]bool{}
+	apply := func(note *misskey.Note) bool {
+		changed := false
+		for n := note; n != nil; n = n.Renote {
+			if n.ID == id && !seen[n] {
+				seen[n] = true
+				n.Reactions = maps.Clone(n.Reactions)
+				if n.Poll != nil {
+					poll := *n.Poll
+					poll.Choices = slices.Clone(poll.Choices)
+					n.Poll = &poll
+				}
+				update(n)
+				changed = true
 			}
-			cmds = append(cmds, cmd)
 		}
+		return changed
 	}
 
-	return m, tea.Batch(cmds...)
+	root := m.root()
+	lists := []*list.Model{&root.list}
+	if root.lastSearch != nil {
+		lists = append(lists, &root.lastSearch.results)
+	}
+	for _, col := range m.columns() {
+		lists = append(lists, &col.list)
+	}
+	for _, s := range m.screens {
+		switch s := s.(type) {
+		case *searchScreen:
+			if s != root.lastSearch {
+				lists = append(lists, &s.results)
+			}
+		case *detailScreen:
+			lists = append(lists, &s.replies)
+			if apply(s.note) {
+				s.viewport.SetContent(s.content())
+			}
+			if s.parent != nil {
+				apply(s.parent)
+			}
+		case *profileScreen:
+			lists = append(lists, &s.notes)
+		case *threadScreen:
+			for i, listItem := range s.notes.Items() {
+				if it, ok := listItem.(threadItem); ok && apply(&it.note) {
+					s.notes.SetItem(i, it)
+				}
+			}
+		}
+	}
+	for _, l := range lists {
+		for i, listItem := range l.Items() {
+			if it, ok := listItem.(item); ok && apply(&it.note) {
+				l.SetItem(i, it)
+			}
+		}
+	}
+}
+
+func removeMyReaction(note *misskey.Note) {
+	if note.MyReaction == "" {
+		return
+	}
+	if note.Reactions[note.MyReaction] > 1 {
+		note.Reactions[note.MyReaction]--
+	} else {
+		delete(note.Reactions, note.MyReaction)
+	}
+	note.MyReaction = ""
+}
+
+// reactionKey returns the key Misskey uses for reaction in Note.Reactions;
+// local custom emoji are stored as ":name@.:".
+func reactionKey(reaction string) string {
+	if strings.HasPrefix(reaction, ":") && strings.HasSuffix(reaction, ":") && !strings.Contains(reaction, "@") {
+		return strings.TrimSuffix(reaction, ":") + "@.:"
+	}
+	return reaction
+}
+
+func (m *model) accountItems() []list.Item {
+	items := make([]list.Item, len(m.config.Accounts))
+	for i, account := range m.config.Accounts {
+		items[i] = accountItem{account: &m.config.Accounts[i], current: account.Name == m.account.Name}
+	}
+	return items
+}
+
+// cancelLoading stops waiting for the request the spinner is shown for.
+// Screens that only exist to show its result are closed; a note being posted
+// cannot be called back, so the composer keeps waiting for it.
+func (m *model) cancelLoading() {
+	switch m.top().(type) {
+	case *postingScreen:
+		return
+	case *notificationsScreen, *antennasScreen, *channelsScreen, *listsScreen, *driveScreen:
+		m.resetViewContext()
+		m.pop()
+	default:
+		m.resetViewContext()
+	}
+	m.opening = nil
+	m.loading = false
+}
+
+// opened reports whether s is the screen being loaded, and if so, stops
+// waiting for it. Screens whose loading was cancelled are dropped.
+func (m *model) opened(s screen) bool {
+	if m.opening != s {
+		return false
+	}
+	m.opening = nil
+	return true
+}
+
+// resetTimelineContext cancels requests for the previous timeline.
+func (m *model) resetTimelineContext() {
+	m.cancelTimeline()
+	m.timelineCtx, m.cancelTimeline = context.WithCancel(context.Background())
+}
+
+// resetViewContext cancels requests for the previous detail or
+// notifications view. Writes such as reactions and follows don't use it, so
+// they finish and report back even after the user has moved on.
+func (m *model) resetViewContext() {
+	m.cancelView()
+	m.viewCtx, m.cancelView = context.WithCancel(context.Background())
+}
+
+// openListPicker shows the user's lists. With member set, choosing a list
+// adds member to it or removes them from it; otherwise it opens the list's
+// timeline.
+func (m *model) openListPicker(member *misskey.User) tea.Cmd {
+	s := newListsScreen(m, member)
+	m.push(s)
+	m.resetViewContext()
+	m.loading = true
+	return tea.Batch(m.spinner.Tick, m.fetchUserListsCmd(s))
+}
+
+// loadMoreFailed reports that another page of a list could not be loaded.
+// Pages abandoned by leaving their screen are not errors.
+func (m *model) loadMoreFailed(err error) tea.Cmd {
+	if abandoned(err) {
+		return nil
+	}
+	m.statusMessage = fmt.Sprintf("Failed to load more: %s", describeError(err))
+	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg { return clearStatusMsg{} })
+}
+
+// appendNoteItems adds the notes that are not in l yet to its end and
+// returns how many were added.
+func appendNoteItems(l *list.Model, notes []misskey.Note) int {
+	ids := map[string]bool{}
+	for _, listItem := range l.Items() {
+		if it, ok := listItem.(item); ok {
+			ids[it.note.ID] = true
+		}
+	}
+	added := 0
+	for _, note := range notes {
+		if !ids[note.ID] {
+			ids[note.ID] = true
+			l.InsertItem(len(l.Items()), item{note: note})
+			added++
+		}
+	}
+	return added
 }
 
 func (m *model) onWindowSizeChanged(msg tea.WindowSizeMsg) {
 	m.width = msg.Width
 	m.height = msg.Height
-	h, v := docStyle.GetFrameSize()
-	m.list.SetSize(msg.Width-h, msg.Height-v-3)
-	m.textarea.SetWidth(msg.Width - h - 4)
-
-	// Detail view adjustments
-	m.viewport.Width = msg.Width - h - 4
-	m.viewport.Height = (msg.Height - v) / 2
-	m.detailList.SetSize(msg.Width-h, msg.Height-v-(m.viewport.Height+8))
+	for _, s := range m.screens {
+		if s, ok := s.(resizer); ok {
+			s.setSize(msg.Width, msg.Height)
+		}
+	}
 }
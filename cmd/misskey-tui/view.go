@@ -4,13 +4,19 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/yulog/misskey-tui/misskey"
 )
 
 func (m *model) statusBarView() string {
 	userInfo := fmt.Sprintf("%s@%s", m.username, m.hostname)
+	streamInfo := offlineIndicatorStyle.Render("○ offline")
+	if m.streaming {
+		streamInfo = liveIndicatorStyle.Render("● live")
+	}
 	statusLeft := statusMessageStyle.Render(m.statusMessage)
-	statusRight := statusMessageStyle.Render(userInfo)
+	statusRight := streamInfo + " " + statusMessageStyle.Render(userInfo)
 
 	spacerWidth := max(m.width-lipgloss.Width(statusLeft)-lipgloss.Width(statusRight), 0)
 
@@ -25,104 +31,113 @@ func (m *model) statusBarView() string {
 }
 
 func (m *model) View() string {
-	if m.err != nil {
-		return fmt.Sprintf("\nAn error occurred: %s\n\nPress any key to return.", m.err)
+	view := m.view()
+	if m.graphics == graphicsKitty && !m.showingMedia() {
+		// Kitty images stay on screen until deleted.
+		view = kittyDeleteAll + view
 	}
+	return view
+}
 
-	if m.loading {
-		return fmt.Sprintf("\n\n   %s Loading...\n\n", m.spinner.View())
+func (m *model) view() string {
+	if m.err != nil {
+		return m.errorView()
 	}
 
-	if m.mode == "posting" {
-		var viewContent strings.Builder
-		if m.replyToNote != nil {
-			quoteAuthor := fmt.Sprintf("@%s", m.replyToNote.User.Username)
-			quoteText := m.replyToNote.Text
-			quote := fmt.Sprintf("%s\n%s", quoteAuthor, quoteText)
-			viewContent.WriteString(quoteBoxStyle.Render(quote))
-			viewContent.WriteString("\n")
+	if m.loading {
+		keys := []key.Binding{m.keys.LoadingCancel, m.keys.LoadingQuit}
+		if _, ok := m.top().(*postingScreen); ok {
+			keys = keys[1:]
 		}
-		viewContent.WriteString(m.textarea.View())
-		viewContent.WriteString("\n\n")
-		viewContent.WriteString(m.help.View(m.keys))
-		dialog := dialogBoxStyle.Render(viewContent.String())
-		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialog)
+		return fmt.Sprintf("\n\n   %s Loading...\n\n   %s\n", m.spinner.View(), m.help.ShortHelpView(keys))
 	}
 
-	if m.mode == "detail" {
-		// 1. Parent Note (if it exists)
-		var parentView string
-		if m.parentNote != nil {
-			parentAuthor := fmt.Sprintf("Replying to @%s", m.parentNote.User.Username)
-			parentInfo := metadataStyle.Render(parentAuthor)
+	return m.top().View(m)
+}
 
-			textWidth := max(m.width-7, 0)
-			wrappedParentText := lipgloss.NewStyle().Width(textWidth).Render(m.parentNote.Text)
+// Kitty image IDs of the images in the detail view, so that drawing one
+// again replaces it.
+const (
+	avatarImageID  = 1
+	previewImageID = 2
+)
 
-			quote := fmt.Sprintf("%s\n%s", parentInfo, wrappedParentText)
-			parentView = quoteBoxStyle.Render(quote)
-		}
+// showingMedia reports whether the view has the detail view's images.
+func (m *model) showingMedia() bool {
+	_, ok := m.top().(*detailScreen)
+	return ok && m.err == nil && !m.loading
+}
 
-		// 2. Main Note View
-		// Note Content View
-		var noteStyle lipgloss.Style
-		if m.detailFocus == "note" {
-			noteStyle = focusedDetailContainerStyle
-		} else {
-			noteStyle = unfocusedDetailContainerStyle
+// imageView renders the image cached under key as a cols x rows block, or
+// text in its place; by default whether it is loading or has no preview.
+func (m *model) imageView(key string, id uint32, cols, rows int, text string) string {
+	if text == "" {
+		if block, ok := m.images.block(m.graphics, key, id, cols, rows, m.cell); ok {
+			return block
 		}
-
-		mainNoteView := noteStyle.Render(m.viewport.View())
-
-		// Calculate heights and set list height
-		status := m.statusBarView()
-		parentHeight := lipgloss.Height(parentView)
-		mainNoteHeight := lipgloss.Height(mainNoteView)
-
-		repliesHeaderStr := "Replies"
-		if m.detailFocus == "replies" {
-			repliesHeaderStr = "Replies (Focused)"
+		text = "Loading..."
+		if _, err := m.images.get(key); err != nil || key == "" {
+			text = "No preview"
 		}
-		repliesHeader := repliesHeaderStyle.Render(repliesHeaderStr)
-		repliesHeaderHeight := lipgloss.Height(repliesHeader)
-
-		statusHeight := lipgloss.Height(status)
-		listHeight := max(m.height-parentHeight-mainNoteHeight-repliesHeaderHeight-statusHeight, 0)
-		m.detailList.SetHeight(listHeight)
-
-		// 3. Replies
-		repliesView := lipgloss.JoinVertical(lipgloss.Left,
-			repliesHeader,
-			m.detailList.View(),
-		)
-
-		// 4. Join them all together
-		finalView := lipgloss.JoinVertical(lipgloss.Left,
-			parentView,
-			mainNoteView,
-			repliesView,
-		)
-
-		return lipgloss.JoinVertical(lipgloss.Left, docStyle.Render(finalView), status)
 	}
+	return lipgloss.NewStyle().Width(cols).Height(rows).MaxHeight(rows).Render(metadataStyle.Render(text))
+}
 
-	// Timeline view
-	timelineTabs := []string{"home", "local", "social", "global"}
-	var renderedTabs []string
-	for _, t := range timelineTabs {
-		var style lipgloss.Style
-		if t == m.timeline {
-			style = activeTabStyle
+// renderFiles lists a note's attachments, marking the one at cursor.
+func renderFiles(files []misskey.DriveFile, cursor, width int) string {
+	lines := []string{metadataStyle.Render("Attachments (f: next, o: open)")}
+	for i, file := range files {
+		line := fmt.Sprintf("%s (%s)", file.Name, file.Type)
+		if file.IsSensitive {
+			line += " " + cwStyle.Render("[sensitive]")
+		}
+		if file.Comment != "" {
+			line += metadataStyle.Render(" — " + file.Comment)
+		}
+		if i == cursor {
+			line = myReactionStyle.Render("›") + " " + line
 		} else {
-			style = inactiveTabStyle
+			line = "  " + line
 		}
-		renderedTabs = append(renderedTabs, style.Render(strings.ToTitle(t)))
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(width).Render(line))
 	}
-	tabHeader := lipgloss.JoinHorizontal(lipgloss.Top, renderedTabs...)
+	return strings.Join(lines, "\n")
+}
 
-	mainContent := docStyle.Render(m.list.View())
+// renderNoteText renders the text of note wrapped to width, with the note it
+// quotes nested below. Notes with a content warning only show the warning
+// unless expanded.
+func renderNoteText(note *misskey.Note, width int, expanded bool) string {
+	if note.Renote != nil && note.Text == "" {
+		note = note.Renote
+	}
+	text := renderMFM(note.Text, width)
+	if note.CW != "" {
+		cw := cwStyle.Render("CW: ") + renderMFM(note.CW, max(width-4, 0))
+		if !expanded {
+			return cw + "\n" + metadataStyle.Render(fmt.Sprintf("%d characters hidden", len([]rune(note.Text))))
+		}
+		text = cw + "\n\n" + text
+	}
+	if note.Renote != nil {
+		text += "\n" + renderQuote(note.Renote, width)
+	}
+	return text
+}
 
-	status := m.statusBarView()
+// renderQuote renders a quoted note in a quote box that fits in width.
+func renderQuote(note *misskey.Note, width int) string {
+	inner := max(width-quoteBoxStyle.GetHorizontalFrameSize(), 1)
+	author := metadataStyle.Render(userTitle(note.User))
+	return quoteBoxStyle.Render(author + "\n" + renderNoteText(note, inner, false))
+}
 
-	return tabHeader + "\n" + mainContent + "\n" + status
+// reactionLabel returns how a reaction key is displayed. Custom emoji keys
+// such as ":blobcat@.:" or ":blobcat@remote.host:" are shown as ":blobcat:".
+func reactionLabel(reaction string) string {
+	if len(reaction) < 2 || !strings.HasPrefix(reaction, ":") || !strings.HasSuffix(reaction, ":") {
+		return reaction
+	}
+	name, _, _ := strings.Cut(strings.Trim(reaction, ":"), "@")
+	return ":" + name + ":"
 }
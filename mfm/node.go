@@ -0,0 +1,106 @@
+// Package mfm parses MFM (Misskey Flavored Markdown) into a syntax tree.
+package mfm
+
+// Kind identifies the type of a Node.
+type Kind int
+
+const (
+	Text Kind = iota
+	Bold
+	Italic
+	Strike
+	Small
+	Center
+	Quote
+	InlineCode
+	CodeBlock
+	Mention
+	Hashtag
+	URL
+	Link
+	Emoji
+	Fn
+)
+
+var kindNames = [...]string{
+	Text:       "Text",
+	Bold:       "Bold",
+	Italic:     "Italic",
+	Strike:     "Strike",
+	Small:      "Small",
+	Center:     "Center",
+	Quote:      "Quote",
+	InlineCode: "InlineCode",
+	CodeBlock:  "CodeBlock",
+	Mention:    "Mention",
+	Hashtag:    "Hashtag",
+	URL:        "URL",
+	Link:       "Link",
+	Emoji:      "Emoji",
+	Fn:         "Fn",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "Unknown"
+}
+
+// Node is an element of the MFM syntax tree. Which fields are set depends on
+// Kind:
+//
+//   - Text, InlineCode, CodeBlock: Value is the literal text (CodeBlock also
+//     sets Lang).
+//   - Mention: Value is the username and Host the remote host, if any.
+//   - Hashtag: Value is the tag without "#".
+//   - Emoji: Value is the emoji name without colons.
+//   - URL: Value is the URL.
+//   - Link: Value is the URL and Children the label; Silent is set for
+//     "?[label](url)".
+//   - Fn: Value is the function name (e.g. "x2", "fg") and Args its
+//     arguments.
+//   - Bold, Italic, Strike, Small, Center, Quote, Fn: Children is the
+//     content.
+type Node struct {
+	Kind     Kind
+	Value    string
+	Host     string
+	Lang     string
+	Silent   bool
+	Args     map[string]string
+	Children []Node
+}
+
+// PlainText returns the text of nodes with all markup removed.
+func PlainText(nodes []Node) string {
+	var b []byte
+	for _, n := range nodes {
+		b = appendPlain(b, n)
+	}
+	return string(b)
+}
+
+func appendPlain(b []byte, n Node) []byte {
+	switch n.Kind {
+	case Text, InlineCode, CodeBlock, URL:
+		return append(b, n.Value...)
+	case Mention:
+		b = append(b, '@')
+		b = append(b, n.Value...)
+		if n.Host != "" {
+			b = append(b, '@')
+			b = append(b, n.Host...)
+		}
+		return b
+	case Hashtag:
+		return append(append(b, '#'), n.Value...)
+	case Emoji:
+		return append(append(append(b, ':'), n.Value...), ':')
+	default:
+		for _, c := range n.Children {
+			b = appendPlain(b, c)
+		}
+		return b
+	}
+}
@@ -0,0 +1,385 @@
+package mfm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth limits how deeply markup may nest, like Misskey's own parser.
+const maxDepth = 20
+
+// Parse parses MFM text into a list of nodes.
+func Parse(text string) []Node {
+	return parseBlocks(text, 0)
+}
+
+// parseBlocks handles the line-based syntax (code blocks and quotes) and
+// hands the remaining text to the inline parser.
+func parseBlocks(text string, depth int) []Node {
+	lines := strings.SplitAfter(text, "\n")
+
+	var nodes []Node
+	var inline strings.Builder
+	flush := func() {
+		if inline.Len() > 0 {
+			nodes = append(nodes, parseInline(inline.String(), depth)...)
+			inline.Reset()
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		line := strings.TrimSuffix(lines[i], "\n")
+
+		if strings.HasPrefix(line, "```") {
+			end := -1
+			for j := i + 1; j < len(lines); j++ {
+				if strings.TrimSuffix(lines[j], "\n") == "```" {
+					end = j
+					break
+				}
+			}
+			if end >= 0 {
+				flush()
+				code := strings.TrimSuffix(strings.Join(lines[i+1:end], ""), "\n")
+				nodes = append(nodes, Node{Kind: CodeBlock, Value: code, Lang: strings.TrimSpace(line[3:])})
+				i = end + 1
+				continue
+			}
+		}
+
+		if strings.HasPrefix(line, ">") && depth < maxDepth {
+			var quoted strings.Builder
+			j := i
+			for ; j < len(lines) && strings.HasPrefix(lines[j], ">"); j++ {
+				quoted.WriteString(strings.TrimPrefix(strings.TrimPrefix(lines[j], ">"), " "))
+			}
+			flush()
+			content := strings.TrimSuffix(quoted.String(), "\n")
+			nodes = append(nodes, Node{Kind: Quote, Children: parseBlocks(content, depth+1)})
+			i = j
+			continue
+		}
+
+		inline.WriteString(lines[i])
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+type inlineParser struct {
+	src   string
+	depth int
+	nodes []Node
+	text  strings.Builder
+}
+
+func parseInline(src string, depth int) []Node {
+	p := &inlineParser{src: src, depth: depth}
+	for i := 0; i < len(src); {
+		if node, n, ok := p.match(i); ok {
+			p.flushText()
+			p.nodes = append(p.nodes, node)
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(src[i:])
+		p.text.WriteString(src[i : i+size])
+		i += size
+	}
+	p.flushText()
+	return p.nodes
+}
+
+func (p *inlineParser) flushText() {
+	if p.text.Len() > 0 {
+		p.nodes = append(p.nodes, Node{Kind: Text, Value: p.text.String()})
+		p.text.Reset()
+	}
+}
+
+// match tries every inline construct at position i and returns the node and
+// the number of bytes it consumed.
+func (p *inlineParser) match(i int) (Node, int, bool) {
+	s := p.src[i:]
+	prev, _ := utf8.DecodeLastRuneInString(p.src[:i])
+	nested := p.depth < maxDepth
+
+	switch {
+	case strings.HasPrefix(s, "<plain>"):
+		if end := strings.Index(s[7:], "</plain>"); end >= 0 {
+			return Node{Kind: Text, Value: s[7 : 7+end]}, 7 + end + 8, true
+		}
+	case s[0] == '`':
+		if end := strings.IndexAny(s[1:], "`\n"); end > 0 && s[1+end] == '`' {
+			return Node{Kind: InlineCode, Value: s[1 : 1+end]}, end + 2, true
+		}
+	case strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"):
+		if n := urlLength(s); n > 0 {
+			return Node{Kind: URL, Value: s[:n]}, n, true
+		}
+	}
+
+	if nested {
+		if node, n, ok := p.matchNested(s, prev); ok {
+			return node, n, true
+		}
+	}
+
+	switch s[0] {
+	case '<':
+		if strings.HasPrefix(s, "<http://") || strings.HasPrefix(s, "<https://") {
+			if end := strings.IndexAny(s, "> \n"); end > 0 && s[end] == '>' {
+				return Node{Kind: URL, Value: s[1:end]}, end + 1, true
+			}
+		}
+	case '@':
+		if !isWordRune(prev) {
+			return matchMention(s)
+		}
+	case '#':
+		if !isWordRune(prev) {
+			return matchHashtag(s)
+		}
+	case ':':
+		if !isWordRune(prev) {
+			return matchEmoji(s)
+		}
+	}
+
+	return Node{}, 0, false
+}
+
+var simpleTags = []struct {
+	open, close string
+	kind        Kind
+}{
+	{"<b>", "</b>", Bold},
+	{"<i>", "</i>", Italic},
+	{"<s>", "</s>", Strike},
+	{"<small>", "</small>", Small},
+	{"<center>", "</center>", Center},
+}
+
+func (p *inlineParser) matchNested(s string, prev rune) (Node, int, bool) {
+	children := func(content string) []Node { return parseInline(content, p.depth+1) }
+
+	switch {
+	case strings.HasPrefix(s, "**"):
+		if end := strings.Index(s[2:], "**"); end > 0 {
+			return Node{Kind: Bold, Children: children(s[2 : 2+end])}, end + 4, true
+		}
+	case strings.HasPrefix(s, "__"):
+		if end := strings.Index(s[2:], "__"); end > 0 && isPlainWords(s[2:2+end]) {
+			return Node{Kind: Bold, Children: children(s[2 : 2+end])}, end + 4, true
+		}
+	case strings.HasPrefix(s, "~~"):
+		if end := strings.IndexAny(s[2:], "~\n"); end > 0 && strings.HasPrefix(s[2+end:], "~~") {
+			return Node{Kind: Strike, Children: children(s[2 : 2+end])}, end + 4, true
+		}
+	case strings.HasPrefix(s, "$["):
+		return p.matchFn(s)
+	case strings.HasPrefix(s, "?[") || s[0] == '[':
+		return p.matchLink(s)
+	case s[0] == '*' || s[0] == '_':
+		if isWordRune(prev) {
+			break
+		}
+		if end := strings.IndexByte(s[1:], s[0]); end > 0 && isPlainWords(s[1:1+end]) {
+			return Node{Kind: Italic, Children: children(s[1 : 1+end])}, end + 2, true
+		}
+	case s[0] == '<':
+		for _, tag := range simpleTags {
+			if !strings.HasPrefix(s, tag.open) {
+				continue
+			}
+			content := s[len(tag.open):]
+			if end := strings.Index(content, tag.close); end >= 0 {
+				return Node{Kind: tag.kind, Children: children(content[:end])}, len(tag.open) + end + len(tag.close), true
+			}
+		}
+	}
+	return Node{}, 0, false
+}
+
+// matchFn parses "$[name.arg1,arg2=value content]".
+func (p *inlineParser) matchFn(s string) (Node, int, bool) {
+	i := 2
+	for i < len(s) && isNameByte(s[i]) {
+		i++
+	}
+	if i == 2 {
+		return Node{}, 0, false
+	}
+	name := s[2:i]
+
+	args := map[string]string{}
+	if i < len(s) && s[i] == '.' {
+		start := i + 1
+		for i < len(s) && s[i] != ' ' && s[i] != '\n' && s[i] != ']' {
+			i++
+		}
+		for _, arg := range strings.Split(s[start:i], ",") {
+			if arg == "" {
+				continue
+			}
+			k, v, _ := strings.Cut(arg, "=")
+			args[k] = v
+		}
+	}
+	if i >= len(s) || (s[i] != ' ' && s[i] != '\n') {
+		return Node{}, 0, false
+	}
+	i++
+
+	start, depth := i, 1
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		if depth == 0 {
+			return Node{Kind: Fn, Value: name, Args: args, Children: parseInline(s[start:i], p.depth+1)}, i + 1, true
+		}
+	}
+	return Node{}, 0, false
+}
+
+// matchLink parses "[label](url)" and "?[label](url)".
+func (p *inlineParser) matchLink(s string) (Node, int, bool) {
+	silent := s[0] == '?'
+	i := 1
+	if silent {
+		i = 2
+	}
+	end := strings.IndexAny(s[i:], "]\n")
+	if end <= 0 || s[i+end] != ']' {
+		return Node{}, 0, false
+	}
+	label := s[i : i+end]
+	rest := s[i+end+1:]
+	if !strings.HasPrefix(rest, "(http://") && !strings.HasPrefix(rest, "(https://") {
+		return Node{}, 0, false
+	}
+	urlEnd := strings.IndexAny(rest, ") \n")
+	if urlEnd < 0 || rest[urlEnd] != ')' {
+		return Node{}, 0, false
+	}
+	n := i + end + 1 + urlEnd + 1
+	return Node{Kind: Link, Value: rest[1:urlEnd], Silent: silent, Children: parseInline(label, p.depth+1)}, n, true
+}
+
+func matchMention(s string) (Node, int, bool) {
+	i := 1
+	for i < len(s) && isMentionByte(s[i]) {
+		i++
+	}
+	username := strings.TrimRight(s[1:i], ".-")
+	if username == "" || username[0] == '.' || username[0] == '-' {
+		return Node{}, 0, false
+	}
+	n := 1 + len(username)
+	node := Node{Kind: Mention, Value: username}
+
+	if n < len(s) && s[n] == '@' {
+		j := n + 1
+		for j < len(s) && isMentionByte(s[j]) {
+			j++
+		}
+		if host := strings.TrimRight(s[n+1:j], ".-"); host != "" {
+			node.Host = host
+			n += 1 + len(host)
+		}
+	}
+	return node, n, true
+}
+
+func matchHashtag(s string) (Node, int, bool) {
+	i := 1
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == ' ' || r == '\t' || r == '\n' || r == '　' || strings.ContainsRune(".,!?'\"#:/[]【】()「」（）<>", r) {
+			break
+		}
+		i += size
+	}
+	tag := s[1:i]
+	if tag == "" || strings.Trim(tag, "0123456789") == "" {
+		return Node{}, 0, false
+	}
+	return Node{Kind: Hashtag, Value: tag}, i, true
+}
+
+func matchEmoji(s string) (Node, int, bool) {
+	i := 1
+	for i < len(s) && (isNameByte(s[i]) || s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	if i == 1 || i >= len(s) || s[i] != ':' {
+		return Node{}, 0, false
+	}
+	if next, _ := utf8.DecodeRuneInString(s[i+1:]); isWordRune(next) {
+		return Node{}, 0, false
+	}
+	return Node{Kind: Emoji, Value: s[1:i]}, i + 1, true
+}
+
+// urlLength returns the length of the URL at the start of s, balancing
+// parentheses and dropping trailing punctuation.
+func urlLength(s string) int {
+	scheme := strings.Index(s, "://") + 3
+	i, parens := scheme, 0
+scan:
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(':
+			parens++
+		case c == ')':
+			if parens == 0 {
+				break scan
+			}
+			parens--
+		case isWordByte(c) || strings.IndexByte("._/:%#@$&?!~=+-,;*'[]", c) >= 0:
+		default:
+			break scan
+		}
+	}
+	for i > scheme && strings.IndexByte(".,", s[i-1]) >= 0 {
+		i--
+	}
+	if i == scheme {
+		return 0
+	}
+	return i
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && isWordByte(byte(r))
+}
+
+func isNameByte(c byte) bool {
+	return isWordByte(c) || c == '_'
+}
+
+func isMentionByte(c byte) bool {
+	return isNameByte(c) || c == '.' || c == '-'
+}
+
+// isPlainWords reports whether s only contains ASCII letters, digits and
+// spaces, as required for "*italic*", "_italic_" and "__bold__".
+func isPlainWords(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isWordByte(s[i]) && s[i] != ' ' && s[i] != '\t' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,313 @@
+package mfm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func text(s string) Node { return Node{Kind: Text, Value: s} }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Node
+	}{
+		{
+			name: "plain text",
+			in:   "hello world",
+			want: []Node{text("hello world")},
+		},
+		{
+			name: "bold",
+			in:   "a **b** c",
+			want: []Node{text("a "), {Kind: Bold, Children: []Node{text("b")}}, text(" c")},
+		},
+		{
+			name: "bold underscores",
+			in:   "__bold__",
+			want: []Node{{Kind: Bold, Children: []Node{text("bold")}}},
+		},
+		{
+			name: "bold tag",
+			in:   "<b>bold</b>",
+			want: []Node{{Kind: Bold, Children: []Node{text("bold")}}},
+		},
+		{
+			name: "italic",
+			in:   "*it* and _it_ and <i>it</i>",
+			want: []Node{
+				{Kind: Italic, Children: []Node{text("it")}},
+				text(" and "),
+				{Kind: Italic, Children: []Node{text("it")}},
+				text(" and "),
+				{Kind: Italic, Children: []Node{text("it")}},
+			},
+		},
+		{
+			name: "italic inside a word",
+			in:   "snake_case_name",
+			want: []Node{text("snake_case_name")},
+		},
+		{
+			name: "strike",
+			in:   "~~gone~~ <s>gone</s>",
+			want: []Node{
+				{Kind: Strike, Children: []Node{text("gone")}},
+				text(" "),
+				{Kind: Strike, Children: []Node{text("gone")}},
+			},
+		},
+		{
+			name: "small",
+			in:   "<small>tiny</small>",
+			want: []Node{{Kind: Small, Children: []Node{text("tiny")}}},
+		},
+		{
+			name: "center",
+			in:   "<center>mid</center>",
+			want: []Node{{Kind: Center, Children: []Node{text("mid")}}},
+		},
+		{
+			name: "nested markup",
+			in:   "**a <i>b</i>**",
+			want: []Node{{Kind: Bold, Children: []Node{text("a "), {Kind: Italic, Children: []Node{text("b")}}}}},
+		},
+		{
+			name: "inline code",
+			in:   "run `**go** test` now",
+			want: []Node{text("run "), {Kind: InlineCode, Value: "**go** test"}, text(" now")},
+		},
+		{
+			name: "plain tag",
+			in:   "<plain>**not bold**</plain>",
+			want: []Node{text("**not bold**")},
+		},
+		{
+			name: "code block",
+			in:   "before\n```go\nfmt.Println(\"**hi**\")\n```\nafter",
+			want: []Node{
+				text("before\n"),
+				{Kind: CodeBlock, Value: "fmt.Println(\"**hi**\")", Lang: "go"},
+				text("after"),
+			},
+		},
+		{
+			name: "code block without language",
+			in:   "```\na\nb\n```",
+			want: []Node{{Kind: CodeBlock, Value: "a\nb"}},
+		},
+		{
+			name: "quote",
+			in:   "> quoted **text**\n> second\nreply",
+			want: []Node{
+				{Kind: Quote, Children: []Node{
+					text("quoted "),
+					{Kind: Bold, Children: []Node{text("text")}},
+					text("\nsecond"),
+				}},
+				text("reply"),
+			},
+		},
+		{
+			name: "nested quote",
+			in:   ">> deep",
+			want: []Node{{Kind: Quote, Children: []Node{{Kind: Quote, Children: []Node{text("deep")}}}}},
+		},
+		{
+			name: "fn",
+			in:   "$[x2 big]",
+			want: []Node{{Kind: Fn, Value: "x2", Args: map[string]string{}, Children: []Node{text("big")}}},
+		},
+		{
+			name: "fn with args",
+			in:   "$[spin.x,speed=2s round]",
+			want: []Node{{Kind: Fn, Value: "spin", Args: map[string]string{"x": "", "speed": "2s"}, Children: []Node{text("round")}}},
+		},
+		{
+			name: "nested fn",
+			in:   "$[fg.color=f00 red $[bg.color=00f on **blue**]]",
+			want: []Node{{
+				Kind:  Fn,
+				Value: "fg",
+				Args:  map[string]string{"color": "f00"},
+				Children: []Node{
+					text("red "),
+					{
+						Kind:  Fn,
+						Value: "bg",
+						Args:  map[string]string{"color": "00f"},
+						Children: []Node{
+							text("on "),
+							{Kind: Bold, Children: []Node{text("blue")}},
+						},
+					},
+				},
+			}},
+		},
+		{
+			name: "fn without content falls back to text",
+			in:   "$[x2]",
+			want: []Node{text("$[x2]")},
+		},
+		{
+			name: "unterminated fn falls back to text",
+			in:   "$[x2 big",
+			want: []Node{text("$[x2 big")},
+		},
+		{
+			name: "link",
+			in:   "see [the **docs**](https://example.com/docs)",
+			want: []Node{
+				text("see "),
+				{Kind: Link, Value: "https://example.com/docs", Children: []Node{
+					text("the "),
+					{Kind: Bold, Children: []Node{text("docs")}},
+				}},
+			},
+		},
+		{
+			name: "silent link",
+			in:   "?[label](https://example.com)",
+			want: []Node{{Kind: Link, Value: "https://example.com", Silent: true, Children: []Node{text("label")}}},
+		},
+		{
+			name: "link without URL falls back to text",
+			in:   "[label](not a url)",
+			want: []Node{text("[label](not a url)")},
+		},
+		{
+			name: "bare URL",
+			in:   "go to https://example.com/a?b=c.",
+			want: []Node{text("go to "), {Kind: URL, Value: "https://example.com/a?b=c"}, text(".")},
+		},
+		{
+			name: "URL with parentheses",
+			in:   "(https://en.wikipedia.org/wiki/Go_(game))",
+			want: []Node{text("("), {Kind: URL, Value: "https://en.wikipedia.org/wiki/Go_(game)"}, text(")")},
+		},
+		{
+			name: "angle bracket URL",
+			in:   "<https://example.com/x>",
+			want: []Node{{Kind: URL, Value: "https://example.com/x"}},
+		},
+		{
+			name: "local mention",
+			in:   "hi @alice!",
+			want: []Node{text("hi "), {Kind: Mention, Value: "alice"}, text("!")},
+		},
+		{
+			name: "remote mention",
+			in:   "@bob@example.com.",
+			want: []Node{{Kind: Mention, Value: "bob", Host: "example.com"}, text(".")},
+		},
+		{
+			name: "email address is not a mention",
+			in:   "mail me@example.com",
+			want: []Node{text("mail me@example.com")},
+		},
+		{
+			name: "hashtag",
+			in:   "#misskey, #日本語 and #123",
+			want: []Node{
+				{Kind: Hashtag, Value: "misskey"},
+				text(", "),
+				{Kind: Hashtag, Value: "日本語"},
+				text(" and #123"),
+			},
+		},
+		{
+			name: "emoji",
+			in:   ":blobcat: :+1:",
+			want: []Node{{Kind: Emoji, Value: "blobcat"}, text(" "), {Kind: Emoji, Value: "+1"}},
+		},
+		{
+			name: "colons that are not emoji",
+			in:   "at 12:30:45 and a:b:c",
+			want: []Node{text("at 12:30:45 and a:b:c")},
+		},
+		{
+			name: "unterminated bold",
+			in:   "**open",
+			want: []Node{text("**open")},
+		},
+		{
+			name: "unterminated strike",
+			in:   "~~open\nclosed~~",
+			want: []Node{text("~~open\nclosed~~")},
+		},
+		{
+			name: "unterminated inline code",
+			in:   "`open\ncode`",
+			want: []Node{text("`open\ncode`")},
+		},
+		{
+			name: "unterminated tag",
+			in:   "<small>open",
+			want: []Node{text("<small>open")},
+		},
+		{
+			name: "unterminated code block",
+			in:   "```\ncode",
+			want: []Node{text("```\ncode")},
+		},
+		{
+			name: "unterminated link",
+			in:   "[label](https://example.com",
+			want: []Node{text("[label]("), {Kind: URL, Value: "https://example.com"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) =\n%#v\nwant\n%#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	in := strings.Repeat("**<i>", 100) + "x" + strings.Repeat("</i>**", 100)
+	depth := 0
+	var walk func(nodes []Node, d int)
+	walk = func(nodes []Node, d int) {
+		depth = max(depth, d)
+		for _, n := range nodes {
+			walk(n.Children, d+1)
+		}
+	}
+	walk(Parse(in), 0)
+	if depth > maxDepth+1 {
+		t.Errorf("tree is %d levels deep, want at most %d", depth, maxDepth+1)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	in := "**hi** @bob@example.com #tag :smile: $[x2 `code`] [label](https://example.com)"
+	want := "hi @bob@example.com #tag :smile: code label"
+	if got := PlainText(Parse(in)); got != want {
+		t.Errorf("PlainText = %q, want %q", got, want)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"**bold** *it* ~~s~~ <small>x</small>",
+		"`code` ```go\ncode\n```",
+		"> quote\n>> nested",
+		"$[fg.color=f00 $[x2 **nested**]]",
+		"[label](https://example.com) https://example.com/(a)",
+		"@user @user@host #tag :emoji:",
+		"**open $[x2 [unclosed <b>",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		nodes := Parse(in)
+		if plain := PlainText(nodes); utf8.ValidString(in) && !utf8.ValidString(plain) {
+			t.Errorf("PlainText(Parse(%q)) = %q is not valid UTF-8", in, plain)
+		}
+	})
+}
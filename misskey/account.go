@@ -0,0 +1,27 @@
+package misskey
+
+import "context"
+
+type NotificationsRequest struct {
+	Limit        int      `json:"limit,omitempty"`
+	SinceID      string   `json:"sinceId,omitempty"`
+	UntilID      string   `json:"untilId,omitempty"`
+	IncludeTypes []string `json:"includeTypes,omitempty"`
+	ExcludeTypes []string `json:"excludeTypes,omitempty"`
+}
+
+// Me fetches the authenticated user.
+func (c *Client) Me(ctx context.Context) (*User, error) {
+	var user User
+	if err := c.post(ctx, "i", nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Notifications fetches the authenticated user's notifications.
+func (c *Client) Notifications(ctx context.Context, req NotificationsRequest) ([]Notification, error) {
+	var notifications []Notification
+	err := c.post(ctx, "i/notifications", req, &notifications)
+	return notifications, err
+}
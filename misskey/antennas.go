@@ -0,0 +1,32 @@
+package misskey
+
+import "context"
+
+// Antenna collects notes matching its conditions into a timeline.
+type Antenna struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Src      string     `json:"src"` // "home", "all", "users", "list", ...
+	Keywords [][]string `json:"keywords,omitempty"`
+}
+
+type AntennaNotesRequest struct {
+	AntennaID string `json:"antennaId"`
+	Limit     int    `json:"limit,omitempty"`
+	SinceID   string `json:"sinceId,omitempty"`
+	UntilID   string `json:"untilId,omitempty"`
+}
+
+// Antennas lists the authenticated user's antennas.
+func (c *Client) Antennas(ctx context.Context) ([]Antenna, error) {
+	var antennas []Antenna
+	err := c.post(ctx, "antennas/list", nil, &antennas)
+	return antennas, err
+}
+
+// AntennaNotes fetches the notes collected by an antenna, newest first.
+func (c *Client) AntennaNotes(ctx context.Context, req AntennaNotesRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "antennas/notes", req, &notes)
+	return notes, err
+}
@@ -0,0 +1,41 @@
+package misskey
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type APShowRequest struct {
+	URI string `json:"uri"`
+}
+
+type apShowResponse struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// APShow resolves an ActivityPub URI, such as a remote profile or note URL,
+// fetching it from the remote server if it is not known yet. Exactly one of
+// the returned user and note is set on success.
+func (c *Client) APShow(ctx context.Context, uri string) (*User, *Note, error) {
+	var resp apShowResponse
+	if err := c.post(ctx, "ap/show", APShowRequest{URI: uri}, &resp); err != nil {
+		return nil, nil, err
+	}
+	switch resp.Type {
+	case "User":
+		var user User
+		if err := json.Unmarshal(resp.Object, &user); err != nil {
+			return nil, nil, err
+		}
+		return &user, nil, nil
+	case "Note":
+		var note Note
+		if err := json.Unmarshal(resp.Object, &note); err != nil {
+			return nil, nil, err
+		}
+		return nil, &note, nil
+	}
+	return nil, nil, fmt.Errorf("ap/show: unexpected object type %q", resp.Type)
+}
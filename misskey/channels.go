@@ -0,0 +1,67 @@
+package misskey
+
+import "context"
+
+type Channel struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	UsersCount  int    `json:"usersCount"`
+	NotesCount  int    `json:"notesCount"`
+	IsFollowing bool   `json:"isFollowing"`
+}
+
+type ChannelsRequest struct {
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type ChannelTimelineRequest struct {
+	ChannelID string `json:"channelId"`
+	Limit     int    `json:"limit,omitempty"`
+	SinceID   string `json:"sinceId,omitempty"`
+	UntilID   string `json:"untilId,omitempty"`
+}
+
+type ChannelRequest struct {
+	ChannelID string `json:"channelId"`
+}
+
+// FollowedChannels lists the channels the authenticated user follows.
+func (c *Client) FollowedChannels(ctx context.Context, req ChannelsRequest) ([]Channel, error) {
+	var channels []Channel
+	err := c.post(ctx, "channels/followed", req, &channels)
+	return channels, err
+}
+
+// FeaturedChannels lists the channels that are currently popular.
+func (c *Client) FeaturedChannels(ctx context.Context) ([]Channel, error) {
+	var channels []Channel
+	err := c.post(ctx, "channels/featured", nil, &channels)
+	return channels, err
+}
+
+// OwnedChannels lists the channels the authenticated user created.
+func (c *Client) OwnedChannels(ctx context.Context, req ChannelsRequest) ([]Channel, error) {
+	var channels []Channel
+	err := c.post(ctx, "channels/owned", req, &channels)
+	return channels, err
+}
+
+// ChannelTimeline fetches the notes posted to a channel, newest first.
+func (c *Client) ChannelTimeline(ctx context.Context, req ChannelTimelineRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "channels/timeline", req, &notes)
+	return notes, err
+}
+
+// FollowChannel follows a channel.
+func (c *Client) FollowChannel(ctx context.Context, channelID string) error {
+	return c.post(ctx, "channels/follow", ChannelRequest{ChannelID: channelID}, nil)
+}
+
+// UnfollowChannel stops following a channel.
+func (c *Client) UnfollowChannel(ctx context.Context, channelID string) error {
+	return c.post(ctx, "channels/unfollow", ChannelRequest{ChannelID: channelID}, nil)
+}
@@ -0,0 +1,118 @@
+// Package misskey is a small client for the Misskey HTTP API.
+package misskey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a single Misskey instance on behalf of one account.
+type Client struct {
+	InstanceURL string
+	AccessToken string
+	HTTPClient  *http.Client
+}
+
+// NewClient returns a Client for instanceURL authenticated with accessToken.
+func NewClient(instanceURL, accessToken string) *Client {
+	return &Client{
+		InstanceURL: instanceURL,
+		AccessToken: accessToken,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Host returns the host part of the instance URL.
+func (c *Client) Host() string {
+	u, err := url.Parse(c.InstanceURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// StreamingURL returns the WebSocket URL of the streaming API, including the
+// access token.
+func (c *Client) StreamingURL() (string, error) {
+	u, err := url.Parse(c.InstanceURL)
+	if err != nil {
+		return "", err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/streaming"
+	u.RawQuery = url.Values{"i": {c.AccessToken}}.Encode()
+	return u.String(), nil
+}
+
+// post calls the API endpoint (e.g. "notes/create") with params merged with
+// the access token, and decodes the response into responseData if non-nil.
+func (c *Client) post(ctx context.Context, endpoint string, params any, responseData any) error {
+	endpointURL, err := url.JoinPath(c.InstanceURL, "api", endpoint)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.requestBody(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(c.HTTPClient, endpoint, req, responseData)
+}
+
+// do sends req and decodes the response into responseData if non-nil.
+func (c *Client) do(httpClient *http.Client, endpoint string, req *http.Request, responseData any) error {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newAPIError(endpoint, resp)
+	}
+
+	if responseData != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(responseData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requestBody encodes params as a JSON object and adds the "i" credential.
+func (c *Client) requestBody(params any) ([]byte, error) {
+	fields := map[string]any{}
+	if params != nil {
+		encoded, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(encoded))
+		dec.UseNumber()
+		if err := dec.Decode(&fields); err != nil {
+			return nil, err
+		}
+	}
+	if c.AccessToken != "" {
+		fields["i"] = c.AccessToken
+	}
+	return json.Marshal(fields)
+}
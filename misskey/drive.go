@@ -0,0 +1,130 @@
+package misskey
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// uploadTimeout bounds file uploads, which may take much longer than the
+// client's default request timeout.
+const uploadTimeout = 5 * time.Minute
+
+type DriveFile struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Size         int64  `json:"size"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	IsSensitive  bool   `json:"isSensitive"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+type DriveFilesRequest struct {
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type UploadFileRequest struct {
+	Name        string
+	IsSensitive bool
+	Comment     string
+}
+
+// UpdateDriveFileRequest changes the metadata of a drive file; nil fields
+// are left unchanged.
+type UpdateDriveFileRequest struct {
+	FileID      string  `json:"fileId"`
+	IsSensitive *bool   `json:"isSensitive,omitempty"`
+	Comment     *string `json:"comment,omitempty"`
+}
+
+// DriveFiles lists the files in the authenticated user's drive, newest first.
+func (c *Client) DriveFiles(ctx context.Context, req DriveFilesRequest) ([]DriveFile, error) {
+	var files []DriveFile
+	err := c.post(ctx, "drive/files", req, &files)
+	return files, err
+}
+
+// UploadFile uploads the content of r to the drive.
+func (c *Client) UploadFile(ctx context.Context, r io.Reader, req UploadFileRequest) (*DriveFile, error) {
+	const endpoint = "drive/files/create"
+	endpointURL, err := url.JoinPath(c.InstanceURL, "api", endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{
+		"i":           c.AccessToken,
+		"name":        req.Name,
+		"isSensitive": strconv.FormatBool(req.IsSensitive),
+	}
+	if req.Comment != "" {
+		fields["comment"] = req.Comment
+	}
+
+	// The form is written into a pipe as the request is sent, so that the
+	// file is never held in memory in full.
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeUploadForm(w, fields, req.Name, r))
+	}()
+	// Closing the reader stops the writer if the request ends early.
+	defer func() {
+		pr.Close()
+		<-done
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, pr)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+
+	httpClient := *c.HTTPClient
+	if httpClient.Timeout != 0 && httpClient.Timeout < uploadTimeout {
+		httpClient.Timeout = uploadTimeout
+	}
+
+	var file DriveFile
+	if err := c.do(&httpClient, endpoint, httpReq, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// writeUploadForm writes the fields and the content of r as the "file"
+// part of a drive/files/create form.
+func writeUploadForm(w *multipart.Writer, fields map[string]string, name string, r io.Reader) error {
+	for field, value := range fields {
+		if err := w.WriteField(field, value); err != nil {
+			return err
+		}
+	}
+	part, err := w.CreateFormFile("file", name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// UpdateDriveFile changes whether a drive file is sensitive and its alt text.
+func (c *Client) UpdateDriveFile(ctx context.Context, req UpdateDriveFileRequest) (*DriveFile, error) {
+	var file DriveFile
+	if err := c.post(ctx, "drive/files/update", req, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
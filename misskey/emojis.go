@@ -0,0 +1,23 @@
+package misskey
+
+import "context"
+
+type Emoji struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases"`
+	Category string   `json:"category"`
+	URL      string   `json:"url"`
+}
+
+type emojisResponse struct {
+	Emojis []Emoji `json:"emojis"`
+}
+
+// Emojis fetches the custom emoji available on the instance.
+func (c *Client) Emojis(ctx context.Context) ([]Emoji, error) {
+	var resp emojisResponse
+	if err := c.post(ctx, "emojis", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Emojis, nil
+}
@@ -0,0 +1,111 @@
+package misskey
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error codes returned by Misskey that callers commonly need to tell apart.
+const (
+	ErrRateLimitExceeded    = "RATE_LIMIT_EXCEEDED"
+	ErrCredentialRequired   = "CREDENTIAL_REQUIRED"
+	ErrAuthenticationFailed = "AUTHENTICATION_FAILED"
+	ErrPermissionDenied     = "PERMISSION_DENIED"
+	ErrNoSuchNote           = "NO_SUCH_NOTE"
+	ErrAlreadyVoted         = "ALREADY_VOTED"
+	ErrAlreadyExpired       = "ALREADY_EXPIRED"
+	ErrNoSuchUser           = "NO_SUCH_USER"
+	ErrAlreadyFollowing     = "ALREADY_FOLLOWING"
+	ErrBlocking             = "BLOCKING"
+	ErrBlocked              = "BLOCKED"
+	ErrUnavailable          = "UNAVAILABLE"
+	ErrNoSuchObject         = "NO_SUCH_OBJECT"
+	ErrNoSuchChannel        = "NO_SUCH_CHANNEL"
+	ErrNoSuchList           = "NO_SUCH_LIST"
+	ErrAlreadyAdded         = "ALREADY_ADDED"
+	ErrTooManyUsers         = "TOO_MANY_USERS"
+	ErrInternalError        = "INTERNAL_ERROR"
+)
+
+// APIError is a non-successful response from the Misskey API. Code, Message
+// and ID come from the JSON error body and are empty when the server did not
+// send one (e.g. a proxy error page); Body then holds the start of whatever
+// plain text it sent instead.
+type APIError struct {
+	Endpoint   string `json:"-"`
+	StatusCode int    `json:"-"`
+	Status     string `json:"-"`
+	Body       string `json:"-"`
+
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	ID      string          `json:"id"`
+	Kind    string          `json:"kind"`
+	Info    json.RawMessage `json:"info,omitempty"`
+}
+
+// maxErrorBody is how much of a non-JSON error body APIError keeps.
+const maxErrorBody = 200
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Code != "" && e.Message != "":
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	case e.Code != "":
+		return e.Code
+	case e.Body != "":
+		return fmt.Sprintf("API request to %s failed: %s: %s", e.Endpoint, e.Status, e.Body)
+	default:
+		return fmt.Sprintf("API request to %s failed: %s", e.Endpoint, e.Status)
+	}
+}
+
+// IsCode reports whether err is an *APIError with the given code.
+func IsCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+func newAPIError(endpoint string, resp *http.Response) *APIError {
+	apiErr := &APIError{
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return apiErr
+	}
+	var wrapper struct {
+		Error *struct {
+			Code    string          `json:"code"`
+			Message string          `json:"message"`
+			ID      string          `json:"id"`
+			Kind    string          `json:"kind"`
+			Info    json.RawMessage `json:"info"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &wrapper); err != nil || wrapper.Error == nil {
+		// Keep plain text, such as a proxy's message, but not HTML pages.
+		text := strings.TrimSpace(string(body))
+		if !strings.HasPrefix(text, "<") {
+			text, _, _ = strings.Cut(text, "\n")
+			if len(text) > maxErrorBody {
+				text = strings.ToValidUTF8(text[:maxErrorBody], "") + "…"
+			}
+			apiErr.Body = text
+		}
+		return apiErr
+	}
+	apiErr.Code = wrapper.Error.Code
+	apiErr.Message = wrapper.Error.Message
+	apiErr.ID = wrapper.Error.ID
+	apiErr.Kind = wrapper.Error.Kind
+	apiErr.Info = wrapper.Error.Info
+	return apiErr
+}
@@ -0,0 +1,46 @@
+package misskey
+
+import "context"
+
+// UserList is a list of users whose notes make up a timeline.
+type UserList struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	UserIDs []string `json:"userIds"`
+}
+
+type UserListTimelineRequest struct {
+	ListID  string `json:"listId"`
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type UserListMemberRequest struct {
+	ListID string `json:"listId"`
+	UserID string `json:"userId"`
+}
+
+// UserLists lists the authenticated user's lists.
+func (c *Client) UserLists(ctx context.Context) ([]UserList, error) {
+	var lists []UserList
+	err := c.post(ctx, "users/lists/list", nil, &lists)
+	return lists, err
+}
+
+// UserListTimeline fetches the notes of a list's members, newest first.
+func (c *Client) UserListTimeline(ctx context.Context, req UserListTimelineRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "notes/user-list-timeline", req, &notes)
+	return notes, err
+}
+
+// AddToUserList adds a user to a list.
+func (c *Client) AddToUserList(ctx context.Context, req UserListMemberRequest) error {
+	return c.post(ctx, "users/lists/push", req, nil)
+}
+
+// RemoveFromUserList removes a user from a list.
+func (c *Client) RemoveFromUserList(ctx context.Context, req UserListMemberRequest) error {
+	return c.post(ctx, "users/lists/pull", req, nil)
+}
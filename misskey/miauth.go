@@ -0,0 +1,69 @@
+package misskey
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MiAuthOptions describes the application asking for a token.
+type MiAuthOptions struct {
+	Name        string
+	Icon        string
+	Callback    string
+	Permissions []string
+}
+
+type MiAuthResult struct {
+	OK    bool   `json:"ok"`
+	Token string `json:"token"`
+	User  *User  `json:"user,omitempty"`
+}
+
+// NewMiAuthSession returns a random session ID for the MiAuth flow.
+func NewMiAuthSession() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// MiAuthURL returns the page on which the user authorizes session.
+func MiAuthURL(instanceURL, session string, opts MiAuthOptions) (string, error) {
+	u, err := url.Parse(instanceURL)
+	if err != nil {
+		return "", err
+	}
+	u = u.JoinPath("miauth", session)
+
+	query := url.Values{}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	if opts.Icon != "" {
+		query.Set("icon", opts.Icon)
+	}
+	if opts.Callback != "" {
+		query.Set("callback", opts.Callback)
+	}
+	if len(opts.Permissions) > 0 {
+		query.Set("permission", strings.Join(opts.Permissions, ","))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// CheckMiAuth asks whether session has been authorized yet. The result's OK
+// is false until the user approves the request.
+func (c *Client) CheckMiAuth(ctx context.Context, session string) (*MiAuthResult, error) {
+	var result MiAuthResult
+	if err := c.post(ctx, "miauth/"+session+"/check", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,191 @@
+package misskey
+
+import "context"
+
+// Timeline identifies one of the built-in note timelines.
+type Timeline int
+
+const (
+	HomeTimeline Timeline = iota
+	LocalTimeline
+	HybridTimeline
+	GlobalTimeline
+)
+
+var timelineEndpoints = map[Timeline]string{
+	HomeTimeline:   "notes/timeline",
+	LocalTimeline:  "notes/local-timeline",
+	HybridTimeline: "notes/hybrid-timeline",
+	GlobalTimeline: "notes/global-timeline",
+}
+
+var timelineChannels = map[Timeline]string{
+	HomeTimeline:   "homeTimeline",
+	LocalTimeline:  "localTimeline",
+	HybridTimeline: "hybridTimeline",
+	GlobalTimeline: "globalTimeline",
+}
+
+// StreamChannel returns the streaming API channel carrying the timeline.
+func (t Timeline) StreamChannel() string {
+	return timelineChannels[t]
+}
+
+// Note visibilities.
+const (
+	VisibilityPublic    = "public"
+	VisibilityHome      = "home"
+	VisibilityFollowers = "followers"
+	VisibilitySpecified = "specified"
+)
+
+type TimelineRequest struct {
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type CreateNoteRequest struct {
+	Text     string `json:"text,omitempty"`
+	CW       string `json:"cw,omitempty"`
+	ReplyID  string `json:"replyId,omitempty"`
+	RenoteID string `json:"renoteId,omitempty"`
+
+	Visibility     string   `json:"visibility,omitempty"`
+	VisibleUserIDs []string `json:"visibleUserIds,omitempty"`
+	LocalOnly      bool     `json:"localOnly,omitempty"`
+
+	FileIDs   []string     `json:"fileIds,omitempty"`
+	Poll      *PollRequest `json:"poll,omitempty"`
+	ChannelID string       `json:"channelId,omitempty"`
+}
+
+// PollRequest attaches a poll to a new note. ExpiresAt is a Unix time and
+// ExpiredAfter a duration, both in milliseconds; set at most one of them.
+type PollRequest struct {
+	Choices      []string `json:"choices"`
+	Multiple     bool     `json:"multiple,omitempty"`
+	ExpiresAt    int64    `json:"expiresAt,omitempty"`
+	ExpiredAfter int64    `json:"expiredAfter,omitempty"`
+}
+
+type createNoteResponse struct {
+	CreatedNote Note `json:"createdNote"`
+}
+
+type NoteRequest struct {
+	NoteID string `json:"noteId"`
+}
+
+type NoteChildrenRequest struct {
+	NoteID  string `json:"noteId"`
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+// NoteConversationRequest asks for the notes noteId replies to, nearest
+// first. Limit is at most 100.
+type NoteConversationRequest struct {
+	NoteID string `json:"noteId"`
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+type SearchNotesRequest struct {
+	Query   string `json:"query"`
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type SearchNotesByTagRequest struct {
+	Tag     string `json:"tag"`
+	Limit   int    `json:"limit,omitempty"`
+	SinceID string `json:"sinceId,omitempty"`
+	UntilID string `json:"untilId,omitempty"`
+}
+
+type PollVoteRequest struct {
+	NoteID string `json:"noteId"`
+	Choice int    `json:"choice"`
+}
+
+type ReactionRequest struct {
+	NoteID   string `json:"noteId"`
+	Reaction string `json:"reaction"`
+}
+
+// Timeline fetches notes from one of the built-in timelines.
+func (c *Client) Timeline(ctx context.Context, timeline Timeline, req TimelineRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, timelineEndpoints[timeline], req, &notes)
+	return notes, err
+}
+
+// CreateNote posts a note, reply or renote and returns the created note.
+func (c *Client) CreateNote(ctx context.Context, req CreateNoteRequest) (*Note, error) {
+	var resp createNoteResponse
+	if err := c.post(ctx, "notes/create", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.CreatedNote, nil
+}
+
+// Renote renotes noteID without adding text.
+func (c *Client) Renote(ctx context.Context, noteID string) (*Note, error) {
+	return c.CreateNote(ctx, CreateNoteRequest{RenoteID: noteID})
+}
+
+// ShowNote fetches a single note.
+func (c *Client) ShowNote(ctx context.Context, req NoteRequest) (*Note, error) {
+	var note Note
+	if err := c.post(ctx, "notes/show", req, &note); err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// NoteChildren fetches the replies and quotes of a note.
+func (c *Client) NoteChildren(ctx context.Context, req NoteChildrenRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "notes/children", req, &notes)
+	return notes, err
+}
+
+// NoteConversation fetches the chain of notes a note replies to, starting
+// with its parent and ending with the root of the thread.
+func (c *Client) NoteConversation(ctx context.Context, req NoteConversationRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "notes/conversation", req, &notes)
+	return notes, err
+}
+
+// SearchNotes finds notes containing the query. Servers may disable it.
+func (c *Client) SearchNotes(ctx context.Context, req SearchNotesRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "notes/search", req, &notes)
+	return notes, err
+}
+
+// SearchNotesByTag finds notes with a hashtag, given without "#".
+func (c *Client) SearchNotesByTag(ctx context.Context, req SearchNotesByTagRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "notes/search-by-tag", req, &notes)
+	return notes, err
+}
+
+// CreateReaction reacts to a note.
+func (c *Client) CreateReaction(ctx context.Context, req ReactionRequest) error {
+	return c.post(ctx, "notes/reactions/create", req, nil)
+}
+
+// DeleteReaction removes the authenticated user's reaction from a note.
+func (c *Client) DeleteReaction(ctx context.Context, req NoteRequest) error {
+	return c.post(ctx, "notes/reactions/delete", req, nil)
+}
+
+// Vote votes for a choice of a note's poll.
+func (c *Client) Vote(ctx context.Context, req PollVoteRequest) error {
+	return c.post(ctx, "notes/polls/vote", req, nil)
+}
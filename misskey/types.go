@@ -0,0 +1,77 @@
+package misskey
+
+type Note struct {
+	ID             string         `json:"id"`
+	Text           string         `json:"text"`
+	CW             string         `json:"cw,omitempty"`
+	Visibility     string         `json:"visibility"`
+	LocalOnly      bool           `json:"localOnly,omitempty"`
+	VisibleUserIDs []string       `json:"visibleUserIds,omitempty"`
+	User           User           `json:"user"`
+	CreatedAt      string         `json:"createdAt"`
+	RepliesCount   int            `json:"repliesCount"`
+	RenoteCount    int            `json:"renoteCount"`
+	Reactions      map[string]int `json:"reactions"`
+	MyReaction     string         `json:"myReaction,omitempty"`
+	ReplyId        string         `json:"replyId,omitempty"`
+	Renote         *Note          `json:"renote,omitempty"`
+	Poll           *Poll          `json:"poll,omitempty"`
+	Files          []DriveFile    `json:"files,omitempty"`
+	ChannelID      string         `json:"channelId,omitempty"`
+	Channel        *Channel       `json:"channel,omitempty"`
+}
+
+type Poll struct {
+	Choices   []PollChoice `json:"choices"`
+	Multiple  bool         `json:"multiple"`
+	ExpiresAt string       `json:"expiresAt,omitempty"`
+}
+
+type PollChoice struct {
+	Text    string `json:"text"`
+	Votes   int    `json:"votes"`
+	IsVoted bool   `json:"isVoted"`
+}
+
+type User struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	Host      string `json:"host,omitempty"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatarUrl,omitempty"`
+}
+
+// UserDetail is a user's profile as returned by users/show, including their
+// relationship to the authenticated user.
+type UserDetail struct {
+	User
+	Description    string      `json:"description,omitempty"`
+	Fields         []UserField `json:"fields,omitempty"`
+	FollowersCount int         `json:"followersCount"`
+	FollowingCount int         `json:"followingCount"`
+	NotesCount     int         `json:"notesCount"`
+	IsLocked       bool        `json:"isLocked"`
+	IsBot          bool        `json:"isBot"`
+
+	IsFollowing                    bool `json:"isFollowing"`
+	IsFollowed                     bool `json:"isFollowed"`
+	HasPendingFollowRequestFromYou bool `json:"hasPendingFollowRequestFromYou"`
+	HasPendingFollowRequestToYou   bool `json:"hasPendingFollowRequestToYou"`
+	IsBlocking                     bool `json:"isBlocking"`
+	IsBlocked                      bool `json:"isBlocked"`
+	IsMuted                        bool `json:"isMuted"`
+}
+
+type UserField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Notification struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"createdAt"`
+	Type      string `json:"type"`
+	User      *User  `json:"user,omitempty"`
+	Note      *Note  `json:"note,omitempty"`
+	Reaction  string `json:"reaction,omitempty"`
+}
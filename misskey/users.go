@@ -0,0 +1,96 @@
+package misskey
+
+import "context"
+
+type UsersRequest struct {
+	UserIDs []string `json:"userIds"`
+}
+
+// ShowUserRequest identifies a user by ID, or by username and host (empty
+// for local users).
+type ShowUserRequest struct {
+	UserID   string `json:"userId,omitempty"`
+	Username string `json:"username,omitempty"`
+	Host     string `json:"host,omitempty"`
+}
+
+type UserNotesRequest struct {
+	UserID      string `json:"userId"`
+	Limit       int    `json:"limit,omitempty"`
+	SinceID     string `json:"sinceId,omitempty"`
+	UntilID     string `json:"untilId,omitempty"`
+	WithReplies bool   `json:"withReplies,omitempty"`
+}
+
+type UserRequest struct {
+	UserID string `json:"userId"`
+}
+
+// SearchUsersRequest searches users by name and username. Origin is
+// "local", "remote" or "combined" (the default).
+type SearchUsersRequest struct {
+	Query  string `json:"query"`
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+	Origin string `json:"origin,omitempty"`
+}
+
+type SearchUsersByUsernameRequest struct {
+	Username string `json:"username,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// Users fetches several users by ID.
+func (c *Client) Users(ctx context.Context, userIDs []string) ([]User, error) {
+	var users []User
+	err := c.post(ctx, "users/show", UsersRequest{UserIDs: userIDs}, &users)
+	return users, err
+}
+
+// ShowUser fetches a user's profile.
+func (c *Client) ShowUser(ctx context.Context, req ShowUserRequest) (*UserDetail, error) {
+	var user UserDetail
+	if err := c.post(ctx, "users/show", req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UserNotes fetches the notes posted by a user, newest first.
+func (c *Client) UserNotes(ctx context.Context, req UserNotesRequest) ([]Note, error) {
+	var notes []Note
+	err := c.post(ctx, "users/notes", req, &notes)
+	return notes, err
+}
+
+// Follow follows a user, or sends a follow request if their account is
+// locked.
+func (c *Client) Follow(ctx context.Context, userID string) error {
+	return c.post(ctx, "following/create", UserRequest{UserID: userID}, nil)
+}
+
+// Unfollow stops following a user.
+func (c *Client) Unfollow(ctx context.Context, userID string) error {
+	return c.post(ctx, "following/delete", UserRequest{UserID: userID}, nil)
+}
+
+// CancelFollowRequest withdraws a pending follow request to a user.
+func (c *Client) CancelFollowRequest(ctx context.Context, userID string) error {
+	return c.post(ctx, "following/requests/cancel", UserRequest{UserID: userID}, nil)
+}
+
+// SearchUsers finds users whose name or username matches the query.
+func (c *Client) SearchUsers(ctx context.Context, req SearchUsersRequest) ([]User, error) {
+	var users []User
+	err := c.post(ctx, "users/search", req, &users)
+	return users, err
+}
+
+// SearchUsersByUsername finds users whose username (and host, if given)
+// starts with the query, for mention and recipient completion.
+func (c *Client) SearchUsersByUsername(ctx context.Context, req SearchUsersByUsernameRequest) ([]User, error) {
+	var users []User
+	err := c.post(ctx, "users/search-by-username-and-host", req, &users)
+	return users, err
+}